@@ -0,0 +1,145 @@
+package ack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Funcs are the transport specific settlement operations. A `nil` function means that the operation is not
+// supported by the transport.
+type Funcs struct {
+	// Ack acknowledges (completes / deletes) the message.
+	Ack func(ctx context.Context) error
+	// Nack abandons the message for re-delivery after _delay_.
+	Nack func(ctx context.Context, delay time.Duration) error
+	// ExtendLease renews the lock (or visibility timeout) of the message by _d_.
+	ExtendLease func(ctx context.Context, d time.Duration) error
+	// DeadLetter moves the message to the dead-letter queue.
+	DeadLetter func(ctx context.Context, reason error) error
+}
+
+// Tracker is the `types.Acknowledger` used by `SubscriberSource` implementations. It makes the settlement
+// idempotent and keeps track of whether the `Subscriber` took ownership of settling the message.
+//
+// The message is only settled once a settlement operation succeeds, i.e. a failed operation may be retried.
+// Concurrent settlement operations are serialized.
+//
+// It is safe for concurrent use.
+type Tracker struct {
+	funcs    Funcs
+	settling sync.Mutex // held while a settlement operation is in flight
+	mu       sync.Mutex
+	manual   bool
+	settled  bool
+}
+
+// NewTracker creates a new `Tracker` settling the message using _funcs_.
+func NewTracker(funcs Funcs) *Tracker {
+	return &Tracker{funcs: funcs}
+}
+
+// Manual takes ownership of settling the message.
+func (t *Tracker) Manual() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.manual = true
+}
+
+// IsManual returns `true` if the `Subscriber` has taken ownership of settling the message.
+func (t *Tracker) IsManual() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.manual
+}
+
+// IsSettled returns `true` if the message has been acked, nacked or dead-lettered.
+func (t *Tracker) IsSettled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.settled
+}
+
+// Ack acknowledges the message.
+func (t *Tracker) Ack(ctx context.Context) error {
+	return t.settle(t.funcs.Ack == nil, func() error { return t.funcs.Ack(ctx) })
+}
+
+// Nack rejects the message for re-delivery after _delay_.
+func (t *Tracker) Nack(ctx context.Context, delay time.Duration) error {
+	return t.settle(t.funcs.Nack == nil, func() error { return t.funcs.Nack(ctx, delay) })
+}
+
+// DeadLetter moves the message to the dead-letter queue.
+func (t *Tracker) DeadLetter(ctx context.Context, reason error) error {
+	return t.settle(t.funcs.DeadLetter == nil, func() error { return t.funcs.DeadLetter(ctx, reason) })
+}
+
+// ExtendLease extends the lease of the message by _d_. It fails with `types.ErrAlreadySettled` once the message
+// has been settled.
+func (t *Tracker) ExtendLease(ctx context.Context, d time.Duration) error {
+	if t.funcs.ExtendLease == nil {
+		return fmt.Errorf("%w: extend lease", types.ErrProtocolMismatch)
+	}
+
+	if t.IsSettled() {
+		return types.ErrAlreadySettled
+	}
+
+	return t.funcs.ExtendLease(ctx, d)
+}
+
+// Settle is invoked by the `SubscriberSource` after `Subscriber.Process` returned _err_ and settles the message
+// unless the `Subscriber` has taken ownership of it (see `IsManual`).
+//
+// A `nil` _err_ acks the message and a `types.ErrBackoff` nacks it with the `types.BackoffError.RetryAfterSeconds`
+// delay. All other errors acks the message, i.e. it is dropped, as documented by `types.Subscriber`. If the
+// transport cannot ack, nothing is done.
+func (t *Tracker) Settle(ctx context.Context, err error) error {
+	if t.IsManual() || t.funcs.Ack == nil {
+		return nil
+	}
+
+	var backoff *types.BackoffError
+	if errors.As(err, &backoff) && t.funcs.Nack != nil {
+		return t.Nack(ctx, time.Duration(backoff.RetryAfterSeconds)*time.Second)
+	}
+
+	return t.Ack(ctx)
+}
+
+func (t *Tracker) settle(unsupported bool, fn func() error) error {
+	if unsupported {
+		return fmt.Errorf("%w: settlement operation", types.ErrProtocolMismatch)
+	}
+
+	t.settling.Lock()
+	defer t.settling.Unlock()
+
+	t.mu.Lock()
+
+	if t.settled {
+		t.mu.Unlock()
+		return types.ErrAlreadySettled
+	}
+
+	t.manual = true
+	t.mu.Unlock()
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.settled = true
+	t.mu.Unlock()
+
+	return nil
+}
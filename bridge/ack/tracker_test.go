@@ -0,0 +1,134 @@
+package ack_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/ack"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ types.Acknowledger = (*ack.Tracker)(nil)
+
+type settlement struct {
+	acks, deadLetters int
+	nackDelay         time.Duration
+	leases            []time.Duration
+}
+
+func (s *settlement) funcs() ack.Funcs {
+	return ack.Funcs{
+		Ack:         func(ctx context.Context) error { s.acks++; return nil },
+		Nack:        func(ctx context.Context, delay time.Duration) error { s.nackDelay = delay; return nil },
+		ExtendLease: func(ctx context.Context, d time.Duration) error { s.leases = append(s.leases, d); return nil },
+		DeadLetter:  func(ctx context.Context, reason error) error { s.deadLetters++; return nil },
+	}
+}
+
+func TestTracker_AsynchronousAck(t *testing.T) {
+	var s settlement
+
+	tracker := ack.NewTracker(s.funcs())
+	done := make(chan struct{})
+
+	sub := types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		a, ok := types.AcknowledgerFromContext(ctx)
+		require.True(t, ok)
+
+		a.Manual()
+
+		go func() {
+			defer close(done)
+
+			assert.NoError(t, a.ExtendLease(context.Background(), time.Minute))
+			assert.NoError(t, a.Ack(context.Background()))
+			assert.ErrorIs(t, a.DeadLetter(context.Background(), nil), types.ErrAlreadySettled)
+			assert.ErrorIs(t, a.ExtendLease(context.Background(), time.Minute), types.ErrAlreadySettled)
+		}()
+
+		return types.ErrInvalidPayload
+	})
+
+	err := sub.Process(types.ContextWithAcknowledger(context.Background(), tracker), "a", types.Message{})
+	require.NoError(t, tracker.Settle(context.Background(), err))
+
+	<-done
+
+	assert.Equal(t, 1, s.acks)
+	assert.Zero(t, s.deadLetters)
+	assert.Equal(t, []time.Duration{time.Minute}, s.leases)
+	assert.True(t, tracker.IsSettled())
+}
+
+func TestTracker_SettleFromProcessResult(t *testing.T) {
+	var s settlement
+
+	tracker := ack.NewTracker(s.funcs())
+	require.NoError(t, tracker.Settle(context.Background(), types.NewBackoffError("slow down", 5)))
+	assert.Equal(t, 5*time.Second, s.nackDelay)
+	assert.Zero(t, s.acks)
+
+	tracker = ack.NewTracker(s.funcs())
+	require.NoError(t, tracker.Settle(context.Background(), types.ErrInvalidPayload))
+	assert.Equal(t, 1, s.acks)
+
+	tracker = ack.NewTracker(ack.Funcs{})
+	assert.ErrorIs(t, tracker.DeadLetter(context.Background(), nil), types.ErrProtocolMismatch)
+	assert.NoError(t, tracker.Settle(context.Background(), nil))
+
+	_, ok := types.AcknowledgerFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestTracker_SettledOnlyOnSuccess(t *testing.T) {
+	var (
+		acks atomic.Int32
+		fail atomic.Bool
+	)
+
+	fail.Store(true)
+
+	tracker := ack.NewTracker(ack.Funcs{Ack: func(ctx context.Context) error {
+		if fail.Load() {
+			return errors.New("connection lost")
+		}
+
+		time.Sleep(time.Millisecond)
+		acks.Add(1)
+
+		return nil
+	}})
+
+	require.Error(t, tracker.Ack(context.Background()))
+	assert.False(t, tracker.IsSettled())
+	assert.True(t, tracker.IsManual())
+
+	fail.Store(false)
+
+	var (
+		wg      sync.WaitGroup
+		settled atomic.Int32
+	)
+
+	for range 8 {
+		wg.Go(func() {
+			if err := tracker.Ack(context.Background()); err == nil {
+				settled.Add(1)
+			} else {
+				assert.ErrorIs(t, err, types.ErrAlreadySettled)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), acks.Load())
+	assert.Equal(t, int32(1), settled.Load())
+	assert.True(t, tracker.IsSettled())
+}
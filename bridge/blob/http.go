@@ -0,0 +1,138 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// HTTPStore is a `Store` for S3 compatible object stores using path-style URLs, i.e. `{endpoint}/{bucket}/{key}`
+// with plain `PUT`, `GET` and `DELETE` requests.
+//
+// Request signing (e.g. AWS SigV4) is not done by the store, provide a _Client_ whose `http.RoundTripper` signs
+// the requests when the bucket is not publicly writable.
+type HTTPStore struct {
+	// Endpoint is the base URL of the object store, e.g. `http://localhost:9000`.
+	Endpoint string
+	// Bucket is the bucket to store the blobs in.
+	Bucket string
+	// Client is the HTTP client to use. If `nil`, `http.DefaultClient` is used.
+	Client *http.Client
+	// MaxSize is the max size, in bytes, of a downloaded object. If zero, `DefaultMaxSize` is used.
+	MaxSize int64
+}
+
+// Put uploads _data_ as the object _key_.
+func (s *HTTPStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	return s.check(resp, key)
+}
+
+// Get downloads the object _key_. Objects larger than `HTTPStore.MaxSize` are rejected with a
+// `types.ErrPayloadTooLarge` wrapped error.
+func (s *HTTPStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if err := s.check(resp, key); err != nil {
+		return nil, err
+	}
+
+	limit := s.MaxSize
+	if limit <= 0 {
+		limit = DefaultMaxSize
+	}
+
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: blob %q exceeds %d bytes", types.ErrPayloadTooLarge, key, limit)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrNetworkUnavailable, err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: blob %q exceeds %d bytes", types.ErrPayloadTooLarge, key, limit)
+	}
+
+	return data, nil
+}
+
+// Delete removes the object _key_.
+func (s *HTTPStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return s.check(resp, key)
+}
+
+func (s *HTTPStore) do(ctx context.Context, method, key string, data []byte) (*http.Response, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	url := strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrNetworkUnavailable, err)
+	}
+
+	return resp, nil
+}
+
+func (s *HTTPStore) check(resp *http.Response, key string) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: blob %q", types.ErrNotFound, key)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: blob %q: %s", types.ErrPermanentAuthFailed, key, resp.Status)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: blob %q: %s", types.ErrBackoff, key, resp.Status)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: blob %q: %s", types.ErrServerUnavailable, key, resp.Status)
+	default:
+		return fmt.Errorf("blob %q: unexpected status %s", key, resp.Status)
+	}
+}
@@ -0,0 +1,107 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// DefaultMaxSize is the default max size, in bytes, of a blob read from a remote `Store`.
+const DefaultMaxSize = 1 << 30
+
+// Store is a blob store where payloads are stored by key.
+type Store interface {
+	// Put stores _data_ under _key_, replacing any existing blob.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the blob stored under _key_. If not found, a `types.ErrNotFound` wrapped error is returned.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the blob stored under _key_. Deleting a non existing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidateKey checks that _key_ is a relative, slash separated, path of `[A-Za-z0-9._-]` segments that do not
+// traverse upwards. Keys are often received in metadata and hence are untrusted.
+//
+// Invalid keys are returned as `types.ErrInvalidPayload` wrapped errors.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: invalid blob key %q", types.ErrInvalidPayload, key)
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: invalid blob key %q", types.ErrInvalidPayload, key)
+		}
+
+		for i := 0; i < len(segment); i++ {
+			c := segment[i]
+
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '_' && c != '-' {
+				return fmt.Errorf("%w: invalid blob key %q", types.ErrInvalidPayload, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// MemoryStore is a in-memory `Store`, mainly for tests. It is safe for concurrent use.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStore creates a new, empty, `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: map[string][]byte{}}
+}
+
+// Put stores a copy of _data_ under _key_.
+func (s *MemoryStore) Put(_ context.Context, key string, data []byte) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blobs[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+// Get returns the blob stored under _key_.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: blob %q", types.ErrNotFound, key)
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+// Delete removes the blob stored under _key_.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+
+	return nil
+}
+
+// Keys returns the keys of all stored blobs.
+func (s *MemoryStore) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Collect(maps.Keys(s.blobs))
+}
@@ -0,0 +1,100 @@
+package blob_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/blob"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T, store blob.Store) {
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "manifests/fw-1.json", []byte("manifest")))
+
+	data, err := store.Get(ctx, "manifests/fw-1.json")
+	require.NoError(t, err)
+	assert.Equal(t, "manifest", string(data))
+
+	require.NoError(t, store.Delete(ctx, "manifests/fw-1.json"))
+	require.NoError(t, store.Delete(ctx, "manifests/fw-1.json"))
+
+	_, err = store.Get(ctx, "manifests/fw-1.json")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	assert.ErrorIs(t, store.Put(ctx, "../escape", nil), types.ErrInvalidPayload)
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	testStore(t, store)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, blob.NewMemoryStore())
+}
+
+func TestHTTPStore(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/bucket/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			_, _ = w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	testStore(t, &blob.HTTPStore{Endpoint: srv.URL, Bucket: "bucket"})
+
+	err := (&blob.HTTPStore{Endpoint: srv.URL, Bucket: "other"}).Put(context.Background(), "a", nil)
+	assert.ErrorIs(t, err, types.ErrPermanentAuthFailed)
+
+	limited := &blob.HTTPStore{Endpoint: srv.URL, Bucket: "bucket", MaxSize: 4}
+	require.NoError(t, limited.Put(context.Background(), "large", []byte("12345")))
+
+	_, err = limited.Get(context.Background(), "large")
+	assert.ErrorIs(t, err, types.ErrPayloadTooLarge)
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "a/b-c_d.json", "2024/05/01/x"} {
+		assert.NoError(t, blob.ValidateKey(key), key)
+	}
+
+	for _, key := range []string{"", "/abs", "a/../b", "a//b", "a/./b", `a\b`, "a b"} {
+		assert.ErrorIs(t, blob.ValidateKey(key), types.ErrInvalidPayload, key)
+	}
+}
@@ -0,0 +1,302 @@
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// For returns the capabilities of _conn_ for _topic_. If the connection do not return topic specific
+// capabilities, its generic capabilities are returned.
+//
+// The generic capabilities are the ones keyed by `""`. If not present, but the connection returns several
+// entries (e.g. one per configured queue), they are merged into the capabilities that hold for all of them
+// (see `Merge`), i.e. it never picks an arbitrary entry.
+func For(conn types.Connection, topic string) types.Capabilities {
+	if caps, ok := conn.Capabilities(topic)[topic]; ok {
+		return caps
+	}
+
+	generic := conn.Capabilities()
+	if caps, ok := generic[""]; ok {
+		return caps
+	}
+
+	keys := slices.Sorted(maps.Keys(generic))
+	entries := make([]types.Capabilities, 0, len(keys))
+
+	for _, key := range keys {
+		entries = append(entries, generic[key])
+	}
+
+	return Merge(entries...)
+}
+
+// Merge returns the capabilities that hold for all _entries_, following the same rules as `Satisfies`:
+//
+// - Limits and `types.CapabilityQoS` only restrict when declared, hence the strictest declared limit and the
+// QoS levels declared by all entries, that declare QoS levels, are used.
+//
+// - Guarantees (`types.CapabilityOrdering`, `types.CapabilityWildcardSyntax`, `types.CapabilityBatch` and
+// flags) must be declared by all entries. The weakest ordering and the smallest batch limits are used and the
+// wildcard syntax only if all entries declare the same.
+func Merge(entries ...types.Capabilities) types.Capabilities {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if len(entries) == 1 {
+		return slices.Clone(entries[0])
+	}
+
+	var merged types.Capabilities
+
+	for _, capability := range entries[0] {
+		if slices.ContainsFunc(merged, func(c types.Capability) bool { return c.Type == capability.Type }) {
+			continue
+		}
+
+		if c, ok := mergeCapability(capability.Type, entries); ok {
+			merged = append(merged, c)
+		}
+	}
+
+	// Limits declared by any but the first entry still restrict.
+	for _, t := range []types.CapabilityType{
+		types.CapabilityMaxPayloadSize, types.CapabilityMaxTopicLength, types.CapabilityQoS,
+	} {
+		if merged.Has(t) {
+			continue
+		}
+
+		if c, ok := mergeCapability(t, entries); ok {
+			merged = append(merged, c)
+		}
+	}
+
+	return merged
+}
+
+// mergeCapability merges the capability of type _t_ of all _entries_, see `Merge`.
+func mergeCapability(t types.CapabilityType, entries []types.Capabilities) (types.Capability, bool) {
+	switch t {
+	case types.CapabilityMaxPayloadSize, types.CapabilityMaxTopicLength:
+		limit, declared := 0, false
+
+		for _, caps := range entries {
+			if v, ok := capabilityInt(caps, t); ok && (!declared || v < limit) {
+				limit, declared = v, true
+			}
+		}
+
+		return types.Capability{Type: t, Value: limit}, declared
+	case types.CapabilityQoS:
+		var (
+			levels   []int
+			declared bool
+		)
+
+		for _, caps := range entries {
+			v, ok := caps.QoSLevels()
+			switch {
+			case !ok:
+			case !declared:
+				levels, declared = slices.Clone(v), true
+			default:
+				levels = slices.DeleteFunc(levels, func(level int) bool { return !slices.Contains(v, level) })
+			}
+		}
+
+		return types.QoSLevels(levels...), declared
+	}
+
+	for _, caps := range entries {
+		if !caps.Has(t) {
+			return types.Capability{}, false
+		}
+	}
+
+	switch t {
+	case types.CapabilityOrdering:
+		ordering := types.OrderingTopic
+
+		for _, caps := range entries {
+			v, _ := caps.Ordering()
+			ordering = min(ordering, v)
+		}
+
+		return types.Ordered(ordering), true
+	case types.CapabilityWildcardSyntax:
+		syntax, _ := entries[0].WildcardSyntax()
+
+		for _, caps := range entries[1:] {
+			if v, _ := caps.WildcardSyntax(); v != syntax {
+				return types.Capability{}, false
+			}
+		}
+
+		return types.Wildcards(syntax), true
+	case types.CapabilityBatch:
+		limits, _ := entries[0].Batch()
+
+		for _, caps := range entries[1:] {
+			v, _ := caps.Batch()
+			limits.MaxCount = min(limits.MaxCount, v.MaxCount)
+
+			if v.MaxBytes > 0 && (limits.MaxBytes == 0 || v.MaxBytes < limits.MaxBytes) {
+				limits.MaxBytes = v.MaxBytes
+			}
+		}
+
+		return types.Batch(limits), true
+	default:
+		c, _ := entries[0].Get(t)
+		return c, true
+	}
+}
+
+// Supports returns `true` if _conn_ provides the requested _capability_ on _topic_.
+//
+// See `Satisfies` for how the requested capability is matched.
+func Supports(conn types.Connection, topic string, capability types.Capability) bool {
+	return Satisfies(For(conn, topic), capability)
+}
+
+// Satisfies returns `true` if _caps_ provides the requested _capability_.
+//
+// Constraints (limits and QoS levels) only restrict when declared, whereas guarantees (ordering, wildcard
+// syntax, batching and flags) must be declared to be satisfied, i.e. an undeclared capability never makes a
+// guarantee the connection has not made.
+//
+// - Limits (`types.CapabilityMaxPayloadSize`, `types.CapabilityMaxTopicLength`) are satisfied when the requested
+// value is within the declared limit. Undeclared limits are treated as unconstrained.
+//
+// - `types.CapabilityQoS` is satisfied when all requested levels are declared. If not declared, it is treated as
+// unconstrained.
+//
+// - `types.CapabilityOrdering` is satisfied when the declared guarantee is at least as strong as requested. If
+// not declared, it is treated as `types.OrderingNone`.
+//
+// - `types.CapabilityWildcardSyntax` is satisfied when the same syntax is declared (or `types.WildcardSyntaxNone`
+// is requested).
+//
+// - `types.CapabilityBatch` is satisfied when the requested batch fits within the declared limits.
+//
+// - All other capabilities are flags and satisfied when declared.
+func Satisfies(caps types.Capabilities, capability types.Capability) bool {
+	switch capability.Type {
+	case types.CapabilityMaxPayloadSize, types.CapabilityMaxTopicLength:
+		want, ok := capability.Value.(int)
+		if !ok {
+			return false
+		}
+
+		limit, declared := capabilityInt(caps, capability.Type)
+
+		return !declared || want <= limit
+	case types.CapabilityQoS:
+		want, _ := capability.Value.([]int)
+
+		if _, declared := caps.QoSLevels(); !declared {
+			return true
+		}
+
+		for _, level := range want {
+			if !caps.SupportsQoS(level) {
+				return false
+			}
+		}
+
+		return true
+	case types.CapabilityOrdering:
+		want, _ := capability.Value.(types.Ordering)
+		have, _ := caps.Ordering()
+
+		return have >= want
+	case types.CapabilityWildcardSyntax:
+		want, _ := capability.Value.(types.WildcardSyntax)
+		if want == types.WildcardSyntaxNone {
+			return true
+		}
+
+		have, _ := caps.WildcardSyntax()
+
+		return have == want
+	case types.CapabilityBatch:
+		want, _ := capability.Value.(types.BatchLimits)
+
+		have, declared := caps.Batch()
+		if !declared {
+			return false
+		}
+
+		return want.MaxCount <= have.MaxCount && (have.MaxBytes == 0 || (want.MaxBytes > 0 && want.MaxBytes <= have.MaxBytes))
+	default:
+		return caps.Has(capability.Type)
+	}
+}
+
+// Validate checks that _conn_ provides all the _required_ capabilities on _topic_, e.g. when a configuration
+// is loaded.
+//
+// Unsupported QoS levels are returned as `types.ErrQoSNotSupported` and other unsupported capabilities as
+// `types.ErrProtocolMismatch` wrapped errors. All failures are joined.
+func Validate(conn types.Connection, topic string, required ...types.Capability) error {
+	caps := For(conn, topic)
+
+	var errs []error
+
+	for _, capability := range required {
+		if Satisfies(caps, capability) {
+			continue
+		}
+
+		sentinel := types.ErrProtocolMismatch
+		if capability.Type == types.CapabilityQoS {
+			sentinel = types.ErrQoSNotSupported
+		}
+
+		errs = append(errs, fmt.Errorf(
+			"%w: connection %q do not support %s=%v on topic %q",
+			sentinel, conn.GetID(), capability.Type, capability.Value, topic,
+		))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateMessage checks that _payload_ can be published on _topic_ according to _caps_.
+//
+// It returns `types.ErrPayloadTooLarge` when the payload exceeds the max payload size, `types.ErrInvalidTopicName`
+// when the topic exceeds the max topic length and `types.ErrQoSNotSupported` when the QoS level is not supported.
+func ValidateMessage(caps types.Capabilities, topic string, payload types.Message) error {
+	if limit, ok := capabilityInt(caps, types.CapabilityMaxPayloadSize); ok && len(payload.Payload) > limit {
+		return fmt.Errorf("%w: %d bytes exceeds the max of %d", types.ErrPayloadTooLarge, len(payload.Payload), limit)
+	}
+
+	if limit, ok := capabilityInt(caps, types.CapabilityMaxTopicLength); ok && len(topic) > limit {
+		return fmt.Errorf("%w: topic exceeds the max length of %d", types.ErrInvalidTopicName, limit)
+	}
+
+	if payload.Qos != nil {
+		if _, declared := caps.QoSLevels(); declared && !caps.SupportsQoS(payload.Qos.Level) {
+			return fmt.Errorf("%w: QoS %d", types.ErrQoSNotSupported, payload.Qos.Level)
+		}
+	}
+
+	return nil
+}
+
+func capabilityInt(caps types.Capabilities, t types.CapabilityType) (int, bool) {
+	switch t {
+	case types.CapabilityMaxPayloadSize:
+		return caps.MaxPayloadSize()
+	case types.CapabilityMaxTopicLength:
+		return caps.MaxTopicLength()
+	default:
+		return 0, false
+	}
+}
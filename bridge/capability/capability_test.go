@@ -0,0 +1,152 @@
+package capability_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/capability"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqsConnection declares SQS FIFO like capabilities, with a stricter limit on the `firmware` topic.
+type sqsConnection struct{}
+
+func (sqsConnection) Close() error                                        { return nil }
+func (sqsConnection) GetID() string                                       { return "sqs" }
+func (sqsConnection) GetTransportType() types.TransportType               { return types.TransportTypeSQS }
+func (sqsConnection) Start(context.Context, types.ConnectionConfig) error { return nil }
+func (sqsConnection) Capabilities(topics ...string) map[string]types.Capabilities {
+	generic := types.Capabilities{
+		types.MaxPayloadSize(256 * 1024),
+		types.MaxTopicLength(80),
+		types.QoSLevels(1),
+		types.Ordered(types.OrderingPartition),
+		types.Wildcards(types.WildcardSyntaxNone),
+		types.Batch(types.BatchLimits{MaxCount: 10, MaxBytes: 256 * 1024}),
+		{Type: types.CapabilityReceiveAtLeastOnce},
+	}
+
+	caps := map[string]types.Capabilities{}
+
+	for _, topic := range topics {
+		if topic == "firmware" {
+			caps[topic] = append(types.Capabilities{types.MaxPayloadSize(1024)}, generic...)
+		}
+	}
+
+	if len(topics) == 0 {
+		caps[""] = generic
+	}
+
+	return caps
+}
+
+func TestSupports(t *testing.T) {
+	conn := sqsConnection{}
+
+	assert.True(t, capability.Supports(conn, "orders", types.MaxPayloadSize(100*1024)))
+	assert.False(t, capability.Supports(conn, "firmware", types.MaxPayloadSize(100*1024)))
+	assert.True(t, capability.Supports(conn, "orders", types.Ordered(types.OrderingPartition)))
+	assert.False(t, capability.Supports(conn, "orders", types.Ordered(types.OrderingTopic)))
+	assert.True(t, capability.Supports(conn, "orders", types.Batch(types.BatchLimits{MaxCount: 10, MaxBytes: 1024})))
+	assert.False(t, capability.Supports(conn, "orders", types.Batch(types.BatchLimits{MaxCount: 100, MaxBytes: 1024})))
+	assert.False(t, capability.Supports(conn, "orders", types.Wildcards(types.WildcardSyntaxMQTT)))
+	assert.True(t, capability.Supports(conn, "orders", types.Capability{Type: types.CapabilityReceiveAtLeastOnce}))
+	assert.False(t, capability.Supports(conn, "orders", types.Capability{Type: types.CapabilityReceiveExactOnce}))
+}
+
+func TestValidate(t *testing.T) {
+	conn := sqsConnection{}
+
+	require.NoError(t, capability.Validate(conn, "orders", types.QoSLevels(1), types.Ordered(types.OrderingPartition)))
+
+	err := capability.Validate(conn, "orders", types.QoSLevels(2), types.Capability{Type: types.CapabilityPublishExactOnce})
+	assert.ErrorIs(t, err, types.ErrQoSNotSupported)
+	assert.ErrorIs(t, err, types.ErrProtocolMismatch)
+
+	caps := capability.For(conn, "firmware")
+	assert.ErrorIs(t, capability.ValidateMessage(caps, "firmware", types.Message{Payload: make([]byte, 2048)}), types.ErrPayloadTooLarge)
+	assert.ErrorIs(t, capability.ValidateMessage(caps, "firmware", types.Message{Qos: &types.QosLevel{Level: 0}}), types.ErrQoSNotSupported)
+	assert.ErrorIs(t, capability.ValidateMessage(caps, string(make([]byte, 81)), types.Message{}), types.ErrInvalidTopicName)
+	assert.NoError(t, capability.ValidateMessage(caps, "firmware", types.Message{Payload: []byte("ok"), Qos: &types.QosLevel{Level: 1}}))
+}
+
+func TestCapabilities_JSONRoundTrip(t *testing.T) {
+	caps := sqsConnection{}.Capabilities()[""]
+
+	data, err := json.Marshal(caps)
+	require.NoError(t, err)
+
+	var got types.Capabilities
+	require.NoError(t, json.Unmarshal(data, &got))
+
+	assert.Equal(t, caps, got)
+
+	levels, ok := got.QoSLevels()
+	require.True(t, ok)
+	assert.Equal(t, []int{1}, levels)
+}
+
+// queuesConnection declares capabilities per configured queue only, without generic (`""`) capabilities.
+type queuesConnection struct{ sqsConnection }
+
+func (queuesConnection) Capabilities(topics ...string) map[string]types.Capabilities {
+	return map[string]types.Capabilities{
+		"orders.fifo": {
+			types.MaxPayloadSize(256 * 1024),
+			types.QoSLevels(0, 1),
+			types.Ordered(types.OrderingPartition),
+			types.Wildcards(types.WildcardSyntaxNone),
+			types.Batch(types.BatchLimits{MaxCount: 10, MaxBytes: 256 * 1024}),
+			{Type: types.CapabilityReceiveAtLeastOnce},
+			{Type: types.CapabilityReceiveExactOnce},
+		},
+		"events": {
+			types.MaxTopicLength(80),
+			types.QoSLevels(1),
+			types.Wildcards(types.WildcardSyntaxNone),
+			types.Batch(types.BatchLimits{MaxCount: 5}),
+			{Type: types.CapabilityReceiveAtLeastOnce},
+		},
+	}
+}
+
+func TestFor_MergesGenericEntries(t *testing.T) {
+	conn := queuesConnection{}
+
+	caps := capability.For(conn, "other")
+
+	size, ok := caps.MaxPayloadSize()
+	require.True(t, ok)
+	assert.Equal(t, 256*1024, size)
+
+	length, ok := caps.MaxTopicLength()
+	require.True(t, ok)
+	assert.Equal(t, 80, length)
+
+	levels, ok := caps.QoSLevels()
+	require.True(t, ok)
+	assert.Equal(t, []int{1}, levels)
+
+	batch, ok := caps.Batch()
+	require.True(t, ok)
+	assert.Equal(t, types.BatchLimits{MaxCount: 5, MaxBytes: 256 * 1024}, batch)
+
+	// Guarantees must be made by all entries.
+	assert.False(t, caps.Has(types.CapabilityOrdering))
+	assert.False(t, caps.Has(types.CapabilityReceiveExactOnce))
+	assert.True(t, caps.Has(types.CapabilityReceiveAtLeastOnce))
+	assert.True(t, caps.Has(types.CapabilityWildcardSyntax))
+
+	// The result do not depend on the map iteration order.
+	for range 10 {
+		assert.Equal(t, caps, capability.For(conn, "other"))
+	}
+
+	assert.False(t, capability.Supports(conn, "other", types.Ordered(types.OrderingPartition)))
+	assert.True(t, capability.Supports(conn, "other", types.Ordered(types.OrderingNone)))
+	assert.False(t, capability.Supports(conn, "other", types.MaxPayloadSize(512*1024)))
+}
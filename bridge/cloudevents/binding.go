@@ -0,0 +1,207 @@
+package cloudevents
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Binding describes how the context attributes of a binary mode event are mapped onto the `types.Message.Metadata`
+// for a specific protocol.
+type Binding struct {
+	// Prefix is prepended to the attribute name to form the metadata key, e.g. `ce-`.
+	//
+	// When empty, all metadata keys that are valid attribute names (see `IsValidAttributeName`) are read as
+	// attributes or extensions.
+	Prefix string
+	// ContentTypeKey is the metadata key holding the content type. The _datacontenttype_ attribute is mapped
+	// onto it in binary mode and `ContentTypeStructured` in structured mode.
+	ContentTypeKey string
+}
+
+var (
+	// BindingDefault maps the attributes onto `ce-` prefixed metadata keys (as the HTTP and Kafka bindings).
+	BindingDefault = Binding{Prefix: "ce-", ContentTypeKey: codec.MetadataKeyContentType}
+	// BindingMQTT5 maps the attributes onto un-prefixed MQTT 5 user properties as of the _MQTT Protocol Binding_.
+	// The content type is carried in the MQTT 5 _Content Type_ property.
+	BindingMQTT5 = Binding{ContentTypeKey: codec.MetadataKeyContentType}
+	// BindingAMQP maps the attributes onto `cloudEvents_` prefixed AMQP application properties as of the
+	// _AMQP Protocol Binding_. The content type is carried in the AMQP _content-type_ property.
+	BindingAMQP = Binding{Prefix: "cloudEvents_", ContentTypeKey: codec.MetadataKeyContentType}
+)
+
+// BindingFor returns the `Binding` for the _transport_. Transports without a dedicated protocol binding
+// use `BindingDefault`.
+func BindingFor(transport types.TransportType) Binding {
+	switch transport {
+	case types.TransportTypeMQTT:
+		return BindingMQTT5
+	case types.TransportTypeAzureServiceBus:
+		return BindingAMQP
+	default:
+		return BindingDefault
+	}
+}
+
+// ToBinary encodes _event_ in binary mode: the _Data_ becomes the `Payload` and the attributes are set in
+// the `Metadata`. The _time_ attribute is rendered as a RFC 3339 string.
+func (b Binding) ToBinary(event Event, topic string) types.Message {
+	meta := make(map[string]any, len(event.Extensions)+8)
+
+	set := func(name, value string) {
+		if value != "" {
+			meta[b.Prefix+name] = value
+		}
+	}
+
+	set(AttributeSpecVersion, specVersion(event))
+	set(AttributeID, event.ID)
+	set(AttributeSource, event.Source)
+	set(AttributeType, event.Type)
+	set(AttributeDataSchema, event.DataSchema)
+	set(AttributeSubject, event.Subject)
+
+	if !event.Time.IsZero() {
+		set(AttributeTime, event.Time.UTC().Format(time.RFC3339Nano))
+	}
+
+	for name, value := range event.Extensions {
+		meta[b.Prefix+name] = value
+	}
+
+	if event.DataContentType != "" {
+		meta[b.ContentTypeKey] = event.DataContentType
+	}
+
+	return types.Message{Topic: topic, Payload: event.Data, Metadata: meta}
+}
+
+// ToStructured encodes _event_ in structured mode: the whole event is rendered as a JSON envelope in the
+// `Payload` and the content type is set to `ContentTypeStructured`.
+func (b Binding) ToStructured(event Event, topic string) (types.Message, error) {
+	data, err := event.MarshalJSON()
+	if err != nil {
+		return types.Message{}, err
+	}
+
+	return types.Message{
+		Topic:    topic,
+		Payload:  data,
+		Metadata: map[string]any{b.ContentTypeKey: ContentTypeStructured},
+	}, nil
+}
+
+// FromMessage decodes a event from _payload_. Structured mode is detected by the `ContentTypeStructured` content
+// type, otherwise binary mode is assumed.
+//
+// When the message is not a valid event, a `types.ErrInvalidPayload` wrapped error is returned.
+func (b Binding) FromMessage(payload types.Message) (Event, error) {
+	ct, _ := payload.Metadata[b.ContentTypeKey].(string)
+
+	if mt, _, err := mime.ParseMediaType(ct); err == nil && strings.HasPrefix(mt, "application/cloudevents") {
+		if mt != ContentTypeStructured {
+			return Event{}, fmt.Errorf("%w: unsupported event format %q", types.ErrInvalidPayload, mt)
+		}
+
+		var event Event
+		if err := event.UnmarshalJSON(payload.Payload); err != nil {
+			return Event{}, err
+		}
+
+		return event, nil
+	}
+
+	return b.fromBinary(payload, ct)
+}
+
+func (b Binding) fromBinary(payload types.Message, ct string) (Event, error) {
+	event := Event{DataContentType: ct, Data: payload.Payload}
+
+	for key, value := range payload.Metadata {
+		if key == b.ContentTypeKey {
+			continue
+		}
+
+		name, ok := strings.CutPrefix(key, b.Prefix)
+		if !ok || !IsValidAttributeName(name) || name == "data" {
+			continue
+		}
+
+		if err := event.set(name, value); err != nil {
+			return Event{}, err
+		}
+	}
+
+	if event.SpecVersion == "" {
+		return Event{}, fmt.Errorf("%w: missing %q attribute, not a cloud event", types.ErrInvalidPayload, AttributeSpecVersion)
+	}
+
+	return event, nil
+}
+
+// set sets the attribute _name_ from a metadata (or JSON) _value_.
+func (e *Event) set(name string, value any) error {
+	if name == AttributeTime {
+		switch t := value.(type) {
+		case time.Time:
+			e.Time = t
+		case string:
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return fmt.Errorf("%w: invalid time attribute: %w", types.ErrInvalidPayload, err)
+			}
+
+			e.Time = parsed
+		default:
+			return fmt.Errorf("%w: invalid time attribute type %T", types.ErrInvalidPayload, value)
+		}
+
+		return nil
+	}
+
+	if !isContextAttribute(name) {
+		if e.Extensions == nil {
+			e.Extensions = map[string]any{}
+		}
+
+		e.Extensions[name] = value
+
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%w: attribute %q must be a string, got %T", types.ErrInvalidPayload, name, value)
+	}
+
+	switch name {
+	case AttributeSpecVersion:
+		e.SpecVersion = s
+	case AttributeID:
+		e.ID = s
+	case AttributeSource:
+		e.Source = s
+	case AttributeType:
+		e.Type = s
+	case AttributeDataContentType:
+		e.DataContentType = s
+	case AttributeDataSchema:
+		e.DataSchema = s
+	case AttributeSubject:
+		e.Subject = s
+	}
+
+	return nil
+}
+
+func specVersion(event Event) string {
+	if event.SpecVersion == "" {
+		return SpecVersion
+	}
+
+	return event.SpecVersion
+}
@@ -0,0 +1,97 @@
+package cloudevents_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/cloudevents"
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event() cloudevents.Event {
+	return cloudevents.Event{
+		ID:              "42",
+		Source:          "/devices/1",
+		Type:            "com.example.reading",
+		Subject:         "temp",
+		DataContentType: codec.ContentTypeJSON,
+		Time:            time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Extensions:      map[string]any{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		Data:            []byte(`{"temp":21.5}`),
+	}
+}
+
+func TestBinding_Binary(t *testing.T) {
+	for name, binding := range map[string]cloudevents.Binding{
+		"default": cloudevents.BindingDefault,
+		"mqtt5":   cloudevents.BindingMQTT5,
+		"amqp":    cloudevents.BindingAMQP,
+	} {
+		t.Run(name, func(t *testing.T) {
+			msg := binding.ToBinary(event(), "devices/1")
+
+			assert.Equal(t, `{"temp":21.5}`, string(msg.Payload))
+			assert.Equal(t, "42", msg.Metadata[binding.Prefix+"id"])
+			assert.Equal(t, "1.0", msg.Metadata[binding.Prefix+"specversion"])
+			assert.Equal(t, codec.ContentTypeJSON, msg.Metadata[codec.MetadataKeyContentType])
+
+			got, err := binding.FromMessage(msg)
+			require.NoError(t, err)
+
+			want := event()
+			want.SpecVersion = cloudevents.SpecVersion
+			assert.Equal(t, want, got)
+		})
+	}
+
+	assert.Equal(t, cloudevents.BindingAMQP, cloudevents.BindingFor(types.TransportTypeAzureServiceBus))
+	assert.Equal(t, cloudevents.BindingMQTT5, cloudevents.BindingFor(types.TransportTypeMQTT))
+}
+
+func TestBinding_Structured(t *testing.T) {
+	msg, err := cloudevents.BindingDefault.ToStructured(event(), "devices/1")
+	require.NoError(t, err)
+
+	assert.Equal(t, cloudevents.ContentTypeStructured, msg.Metadata[codec.MetadataKeyContentType])
+	assert.JSONEq(t, `{
+		"specversion":"1.0","id":"42","source":"/devices/1","type":"com.example.reading","subject":"temp",
+		"datacontenttype":"application/json","time":"2024-05-01T12:00:00Z",
+		"traceparent":"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01","data":{"temp":21.5}
+	}`, string(msg.Payload))
+
+	got, err := cloudevents.BindingDefault.FromMessage(msg)
+	require.NoError(t, err)
+	assert.Equal(t, "42", got.ID)
+	assert.JSONEq(t, `{"temp":21.5}`, string(got.Data))
+
+	binary := event()
+	binary.DataContentType = "application/octet-stream"
+	binary.Data = []byte{0xde, 0xad}
+
+	msg, err = cloudevents.BindingDefault.ToStructured(binary, "devices/1")
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Payload), `"data_base64":"3q0="`)
+
+	got, err = cloudevents.BindingDefault.FromMessage(msg)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xde, 0xad}, got.Data)
+}
+
+func TestBinding_Invalid(t *testing.T) {
+	_, err := cloudevents.BindingDefault.FromMessage(types.Message{Payload: []byte("{}")})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+
+	_, err = cloudevents.BindingDefault.FromMessage(types.Message{
+		Payload:  []byte(`{"id":"1"}`),
+		Metadata: map[string]any{codec.MetadataKeyContentType: cloudevents.ContentTypeStructured},
+	})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+
+	ev := event()
+	assert.NoError(t, ev.Validate("subject", "traceparent"))
+	assert.ErrorIs(t, ev.Validate("dataschema"), types.ErrInvalidPayload)
+	assert.ErrorIs(t, ev.SetExtension("Bad-Name", 1), types.ErrInvalidPayload)
+}
@@ -0,0 +1,144 @@
+package cloudevents
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// SpecVersion is the supported _CloudEvents_ specification version.
+const SpecVersion = "1.0"
+
+// ContentTypeStructured is the content type of a structured mode (JSON format) event.
+const ContentTypeStructured = "application/cloudevents+json"
+
+// Context attribute names as defined by the _CloudEvents 1.0_ specification.
+const (
+	AttributeSpecVersion     = "specversion"
+	AttributeID              = "id"
+	AttributeSource          = "source"
+	AttributeType            = "type"
+	AttributeDataContentType = "datacontenttype"
+	AttributeDataSchema      = "dataschema"
+	AttributeSubject         = "subject"
+	AttributeTime            = "time"
+)
+
+// Event is a _CloudEvents 1.0_ event.
+type Event struct {
+	// SpecVersion is the specification version, if empty, `SpecVersion` is assumed when encoding.
+	SpecVersion string
+	// ID identifies the event, it is unique within the scope of the _Source_.
+	ID string
+	// Source identifies the context in which the event happened (URI-reference).
+	Source string
+	// Type is the type of event related to the originating occurrence, e.g. `com.example.device.reading`.
+	Type string
+	// DataContentType is the content type of _Data_, e.g. `application/json`.
+	DataContentType string
+	// DataSchema optionally identifies the schema that _Data_ adheres to.
+	DataSchema string
+	// Subject optionally describes the subject of the event in the context of the _Source_.
+	Subject string
+	// Time is the optional timestamp of when the occurrence happened.
+	Time time.Time
+	// Extensions holds the extension context attributes by (lower case) name.
+	Extensions map[string]any
+	// Data is the event payload.
+	Data []byte
+}
+
+// Attribute returns the value of the context attribute or extension _name_, if set.
+func (e *Event) Attribute(name string) (any, bool) {
+	var v string
+
+	switch name {
+	case AttributeSpecVersion:
+		v = e.SpecVersion
+	case AttributeID:
+		v = e.ID
+	case AttributeSource:
+		v = e.Source
+	case AttributeType:
+		v = e.Type
+	case AttributeDataContentType:
+		v = e.DataContentType
+	case AttributeDataSchema:
+		v = e.DataSchema
+	case AttributeSubject:
+		v = e.Subject
+	case AttributeTime:
+		if e.Time.IsZero() {
+			return nil, false
+		}
+
+		return e.Time, true
+	default:
+		ext, ok := e.Extensions[name]
+		return ext, ok && ext != nil
+	}
+
+	return v, v != ""
+}
+
+// SetExtension sets the extension attribute _name_. Names must be lower case alphanumeric
+// (see `IsValidAttributeName`) and must not collide with a context attribute.
+func (e *Event) SetExtension(name string, value any) error {
+	if !IsValidAttributeName(name) || isContextAttribute(name) || name == "data" {
+		return fmt.Errorf("%w: invalid extension attribute name %q", types.ErrInvalidPayload, name)
+	}
+
+	if e.Extensions == nil {
+		e.Extensions = map[string]any{}
+	}
+
+	e.Extensions[name] = value
+
+	return nil
+}
+
+// Validate checks that the required context attributes are set and that the _SpecVersion_ is supported.
+//
+// The _required_ names are additionally required to be set, e.g. `subject` or a extension.
+func (e *Event) Validate(required ...string) error {
+	if e.SpecVersion != "" && e.SpecVersion != SpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", types.ErrInvalidPayload, e.SpecVersion)
+	}
+
+	for _, name := range append([]string{AttributeID, AttributeSource, AttributeType}, required...) {
+		if _, ok := e.Attribute(name); !ok {
+			return fmt.Errorf("%w: missing required attribute %q", types.ErrInvalidPayload, name)
+		}
+	}
+
+	return nil
+}
+
+// IsValidAttributeName returns `true` if _name_ consists of lower case ASCII letters and digits only.
+//
+// NOTE: The specification recommends that names are no longer than 20 characters, this is not enforced.
+func IsValidAttributeName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isContextAttribute(name string) bool {
+	switch name {
+	case AttributeSpecVersion, AttributeID, AttributeSource, AttributeType,
+		AttributeDataContentType, AttributeDataSchema, AttributeSubject, AttributeTime:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// MarshalJSON renders the event in the _CloudEvents JSON Event Format_.
+//
+// When _DataContentType_ is JSON (or empty) and _Data_ is valid JSON, it is embedded as `data`, otherwise it
+// is base64 encoded as `data_base64`.
+func (e Event) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(e.Extensions)+9)
+
+	for name, value := range e.Extensions {
+		m[name] = value
+	}
+
+	m[AttributeSpecVersion] = specVersion(e)
+	m[AttributeID] = e.ID
+	m[AttributeSource] = e.Source
+	m[AttributeType] = e.Type
+
+	for name, value := range map[string]string{
+		AttributeDataContentType: e.DataContentType,
+		AttributeDataSchema:      e.DataSchema,
+		AttributeSubject:         e.Subject,
+	} {
+		if value != "" {
+			m[name] = value
+		}
+	}
+
+	if !e.Time.IsZero() {
+		m[AttributeTime] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	if e.Data != nil {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			m["data"] = json.RawMessage(e.Data)
+		} else {
+			m["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalJSON parses an event in the _CloudEvents JSON Event Format_.
+//
+// A JSON `data` member is kept as is when the _DataContentType_ is JSON (or empty), a JSON string `data` with
+// a non JSON content type is unquoted.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	*e = Event{}
+
+	for name, value := range raw {
+		if name == "data" || name == "data_base64" {
+			continue
+		}
+
+		var v any
+
+		dec := json.NewDecoder(bytes.NewReader(value))
+		dec.UseNumber()
+
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%w: attribute %q: %w", types.ErrInvalidPayload, name, err)
+		}
+
+		if v == nil {
+			continue
+		}
+
+		if err := e.set(name, v); err != nil {
+			return err
+		}
+	}
+
+	if e.SpecVersion == "" {
+		return fmt.Errorf("%w: missing %q attribute, not a cloud event", types.ErrInvalidPayload, AttributeSpecVersion)
+	}
+
+	if b64, ok := raw["data_base64"]; ok {
+		var s string
+		if err := json.Unmarshal(b64, &s); err != nil {
+			return fmt.Errorf("%w: data_base64: %w", types.ErrInvalidPayload, err)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("%w: data_base64: %w", types.ErrInvalidPayload, err)
+		}
+
+		e.Data = decoded
+	} else if d, ok := raw["data"]; ok {
+		var s string
+		if !isJSON(e.DataContentType) && json.Unmarshal(d, &s) == nil {
+			e.Data = []byte(s)
+		} else {
+			e.Data = []byte(d)
+		}
+	}
+
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
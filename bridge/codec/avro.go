@@ -0,0 +1,269 @@
+package codec
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/hamba/avro/v2"
+)
+
+// ContentTypeAvro is the content type of _Avro_ binary encoded payloads (without the object container).
+const ContentTypeAvro = "application/avro"
+
+// Avro is a codec that encodes values using a single _Avro_ schema, e.g. loaded from local `.avsc` files.
+//
+// Structs are encoded and decoded directly using their `avro` struct tags. All other values, such as the
+// generic data model used by `Transcode`, are converted through their JSON representation and coerced onto
+// the schema, e.g. a JSON number is converted into an _Avro_ `int`, `long`, `float` or `double`. Missing
+// record fields get their schema default or `null` if the field is nullable.
+type Avro struct {
+	schema avro.Schema
+}
+
+// NewAvro creates a new `Avro` codec from the JSON _schema_.
+func NewAvro(schema string) (*Avro, error) {
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+
+	return &Avro{schema: s}, nil
+}
+
+// LoadAvro creates a new `Avro` codec from the local schema files in _paths_. Named types declared in a file
+// may be referenced by the files following it and the schema of the last file is used by the codec.
+func LoadAvro(paths ...string) (*Avro, error) {
+	s, err := avro.ParseFiles(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("avro: failed to load schema: %w", err)
+	}
+
+	return &Avro{schema: s}, nil
+}
+
+// Schema returns the schema of the codec.
+func (a *Avro) Schema() avro.Schema { return a.schema }
+
+// ContentType returns `application/avro`.
+func (a *Avro) ContentType() string { return ContentTypeAvro }
+
+// Marshal encodes _v_ using the schema.
+func (a *Avro) Marshal(v any) ([]byte, error) {
+	if isStruct(v) {
+		return avro.Marshal(a.schema, v)
+	}
+
+	g, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err = coerceAvro(a.schema, g, "$")
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+
+	return avro.Marshal(a.schema, g)
+}
+
+// Unmarshal decodes _data_ into _v_ using the schema.
+func (a *Avro) Unmarshal(data []byte, v any) error {
+	if isStruct(v) {
+		return avro.Unmarshal(a.schema, data, v)
+	}
+
+	var g any
+	if err := avro.Unmarshal(a.schema, data, &g); err != nil {
+		return err
+	}
+
+	return fromGeneric(g, v)
+}
+
+// isStruct returns `true` if _v_ is a struct or a pointer to one.
+func isStruct(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t != nil && t.Kind() == reflect.Struct
+}
+
+// coerceAvro converts the generic value _g_ into the Go types expected by the _schema_.
+func coerceAvro(schema avro.Schema, g any, path string) (any, error) {
+	switch s := schema.(type) {
+	case *avro.RefSchema:
+		return coerceAvro(s.Schema(), g, path)
+	case *avro.RecordSchema:
+		obj, ok := g.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object for record %s, got %T", path, s.FullName(), g)
+		}
+
+		out := make(map[string]any, len(s.Fields()))
+
+		for _, f := range s.Fields() {
+			value, ok := obj[f.Name()]
+
+			switch {
+			case ok:
+				c, err := coerceAvro(f.Type(), value, childPath(path, f.Name()))
+				if err != nil {
+					return nil, err
+				}
+
+				out[f.Name()] = c
+			case f.HasDefault():
+				out[f.Name()] = f.Default()
+			case isNullable(f.Type()):
+				out[f.Name()] = nil
+			default:
+				return nil, fmt.Errorf("%s: required field is missing", childPath(path, f.Name()))
+			}
+		}
+
+		return out, nil
+	case *avro.MapSchema:
+		obj, ok := g.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object for map, got %T", path, g)
+		}
+
+		out := make(map[string]any, len(obj))
+
+		for k, value := range obj {
+			c, err := coerceAvro(s.Values(), value, childPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+
+			out[k] = c
+		}
+
+		return out, nil
+	case *avro.ArraySchema:
+		arr, ok := g.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected array, got %T", path, g)
+		}
+
+		out := make([]any, len(arr))
+
+		for i, value := range arr {
+			c, err := coerceAvro(s.Items(), value, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = c
+		}
+
+		return out, nil
+	case *avro.UnionSchema:
+		if g == nil {
+			return nil, nil
+		}
+
+		// The first member that accepts the value is used.
+		for _, member := range s.Types() {
+			if member.Type() == avro.Null {
+				continue
+			}
+
+			if c, err := coerceAvro(member, g, path); err == nil {
+				return c, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%s: %T does not match any type of the union", path, g)
+	case *avro.EnumSchema, *avro.FixedSchema:
+		return coercePrimitive(schema.Type(), g, path)
+	case *avro.PrimitiveSchema:
+		return coercePrimitive(s.Type(), g, path)
+	default:
+		return g, nil
+	}
+}
+
+func coercePrimitive(typ avro.Type, g any, path string) (any, error) {
+	switch typ {
+	case avro.Null:
+		if g == nil {
+			return nil, nil
+		}
+	case avro.Boolean:
+		if b, ok := g.(bool); ok {
+			return b, nil
+		}
+	case avro.String, avro.Enum:
+		if s, ok := g.(string); ok {
+			return s, nil
+		}
+	case avro.Bytes, avro.Fixed:
+		switch t := g.(type) {
+		case []byte:
+			return t, nil
+		case string:
+			// `[]byte` values are represented as base64 in the JSON data model.
+			if b, err := base64.StdEncoding.DecodeString(t); err == nil {
+				return b, nil
+			}
+		}
+	case avro.Int, avro.Long:
+		if i, ok := integral(g); ok {
+			if typ == avro.Long {
+				return i, nil
+			}
+
+			if i >= math.MinInt32 && i <= math.MaxInt32 {
+				return int(i), nil
+			}
+		}
+	case avro.Float, avro.Double:
+		var f float64
+
+		switch t := g.(type) {
+		case float64:
+			f = t
+		case int64:
+			f = float64(t)
+		case uint64:
+			f = float64(t)
+		default:
+			return nil, fmt.Errorf("%s: %T is not a %s", path, g, typ)
+		}
+
+		if typ == avro.Float {
+			return float32(f), nil
+		}
+
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("%s: %T is not a %s", path, g, typ)
+}
+
+func integral(g any) (int64, bool) {
+	switch t := g.(type) {
+	case int64:
+		return t, true
+	case uint64:
+		return int64(t), t <= math.MaxInt64
+	case float64:
+		return int64(t), t == math.Trunc(t) && t >= math.MinInt64 && t < math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+func isNullable(schema avro.Schema) bool {
+	u, ok := schema.(*avro.UnionSchema)
+	return ok && u.Nullable()
+}
+
+func childPath(path, name string) string {
+	return path + "." + name
+}
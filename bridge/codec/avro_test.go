@@ -0,0 +1,75 @@
+package codec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readingSchema = `{
+	"type": "record",
+	"name": "Reading",
+	"namespace": "telemetry.v1",
+	"fields": [
+		{"name": "device", "type": "string"},
+		{"name": "temp", "type": "double"},
+		{"name": "count", "type": "int"},
+		{"name": "unit", "type": ["null", "string"]},
+		{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []}
+	]
+}`
+
+type avroReading struct {
+	Device string   `avro:"device"`
+	Temp   float64  `avro:"temp"`
+	Count  int      `avro:"count"`
+	Unit   *string  `avro:"unit"`
+	Tags   []string `avro:"tags"`
+}
+
+func TestAvro_LocalSchemaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reading.avsc")
+	require.NoError(t, os.WriteFile(path, []byte(readingSchema), 0o600))
+
+	av, err := codec.LoadAvro(path)
+	require.NoError(t, err)
+
+	unit := "C"
+	in := avroReading{Device: "dev-1", Temp: 21.5, Count: 3, Unit: &unit, Tags: []string{"a"}}
+
+	data, err := av.Marshal(in)
+	require.NoError(t, err)
+
+	var out avroReading
+	require.NoError(t, av.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	// JSON numbers are coerced onto the schema and missing fields get their default or null.
+	data, err = codec.Transcode([]byte(`{"device": "dev-2", "temp": 20, "count": 7}`), codec.JSON{}, av)
+	require.NoError(t, err)
+
+	var coerced avroReading
+	require.NoError(t, av.Unmarshal(data, &coerced))
+	assert.Equal(t, avroReading{Device: "dev-2", Temp: 20, Count: 7, Tags: []string{}}, coerced)
+
+	js, err := codec.Transcode(data, av, codec.JSON{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"device":"dev-2","temp":20,"count":7,"unit":null,"tags":[]}`, string(js))
+
+	for _, invalid := range []string{
+		`{"temp": 20, "count": 7}`,
+		`{"device": "dev-1", "temp": 20, "count": 1.5}`,
+		`{"device": "dev-1", "temp": 20, "count": 4294967296}`,
+		`{"device": "dev-1", "temp": "hot", "count": 1}`,
+	} {
+		_, err := codec.Transcode([]byte(invalid), codec.JSON{}, av)
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = codec.NewAvro(`{"type": "record"}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,356 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// maxDepth is the maximum nesting of arrays and maps accepted when decoding.
+const maxDepth = 512
+
+var errTruncated = errors.New("truncated input")
+
+// CBOR is a _RFC 8949_ codec for the generic data model. Go values are converted through their JSON
+// representation (see `json` struct tags) hence `[]byte` fields nested in structs are encoded as base64
+// text strings. A top level `[]byte` is encoded as a byte string.
+//
+// Maps are encoded with sorted keys. Tags are ignored when decoding (the tagged value is returned).
+type CBOR struct{}
+
+// ContentType returns `application/cbor`.
+func (CBOR) ContentType() string { return ContentTypeCBOR }
+
+// Marshal encodes _v_ as CBOR.
+func (CBOR) Marshal(v any) ([]byte, error) {
+	g, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCBOR(nil, g)
+}
+
+// Unmarshal decodes the CBOR _data_ into _v_.
+func (CBOR) Unmarshal(data []byte, v any) error {
+	d := &cborDecoder{data: data}
+
+	g, err := d.decode(0)
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+
+	if d.pos != len(data) {
+		return fmt.Errorf("cbor: %d trailing bytes", len(data)-d.pos)
+	}
+
+	return fromGeneric(g, v)
+}
+
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+func appendCBOR(b []byte, v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return append(b, 0xf6), nil
+	case bool:
+		if t {
+			return append(b, 0xf5), nil
+		}
+
+		return append(b, 0xf4), nil
+	case int64:
+		if t < 0 {
+			return appendCBORHead(b, 1, uint64(-(t + 1))), nil
+		}
+
+		return appendCBORHead(b, 0, uint64(t)), nil
+	case uint64:
+		return appendCBORHead(b, 0, t), nil
+	case float64:
+		if t == math.Trunc(t) && t >= math.MinInt64 && t < math.MaxInt64 && !math.IsInf(t, 0) {
+			return appendCBOR(b, int64(t))
+		}
+
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(t)), nil
+	case string:
+		return append(appendCBORHead(b, 3, uint64(len(t))), t...), nil
+	case []byte:
+		return append(appendCBORHead(b, 2, uint64(len(t))), t...), nil
+	case []any:
+		b = appendCBORHead(b, 4, uint64(len(t)))
+
+		for _, e := range t {
+			var err error
+			if b, err = appendCBOR(b, e); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	case map[string]any:
+		b = appendCBORHead(b, 5, uint64(len(t)))
+
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			b = append(appendCBORHead(b, 3, uint64(len(k))), k...)
+
+			var err error
+			if b, err = appendCBOR(b, t[k]); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported type %T", v)
+	}
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errTruncated
+	}
+
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return b, nil
+}
+
+// head reads the initial byte and argument. _indefinite_ is `true` for the indefinite length encoding.
+func (d *cborDecoder) head() (major, info byte, arg uint64, indefinite bool, err error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	major, info = b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info == 24:
+		b, err = d.read(1)
+		if err != nil {
+			return
+		}
+
+		return major, info, uint64(b[0]), false, nil
+	case info == 25:
+		b, err = d.read(2)
+		if err != nil {
+			return
+		}
+
+		return major, info, uint64(binary.BigEndian.Uint16(b)), false, nil
+	case info == 26:
+		b, err = d.read(4)
+		if err != nil {
+			return
+		}
+
+		return major, info, uint64(binary.BigEndian.Uint32(b)), false, nil
+	case info == 27:
+		b, err = d.read(8)
+		if err != nil {
+			return
+		}
+
+		return major, info, binary.BigEndian.Uint64(b), false, nil
+	case info == 31 && major >= 2 && major <= 5:
+		return major, info, 0, true, nil
+	case info == 31 && major == 7:
+		return major, info, 0, false, errors.New("unexpected break")
+	default:
+		return major, info, 0, false, fmt.Errorf("invalid additional information %d", info)
+	}
+}
+
+func (d *cborDecoder) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+
+	return false
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("maximum nesting depth exceeded")
+	}
+
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg <= math.MaxInt64 {
+			return int64(arg), nil
+		}
+
+		return arg, nil
+	case 1:
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg), nil
+		}
+
+		return -1 - float64(arg), nil
+	case 2, 3:
+		var buf []byte
+
+		if indefinite {
+			for !d.isBreak() {
+				chunk, err := d.decode(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+
+				switch c := chunk.(type) {
+				case []byte:
+					buf = append(buf, c...)
+				case string:
+					buf = append(buf, c...)
+				default:
+					return nil, errors.New("invalid chunk in indefinite length string")
+				}
+			}
+		} else {
+			b, err := d.read(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			buf = slices.Clone(b)
+		}
+
+		if major == 3 {
+			return string(buf), nil
+		}
+
+		return buf, nil
+	case 4:
+		arr := make([]any, 0, min(arg, uint64(len(d.data)-d.pos)))
+
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+
+			e, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			arr = append(arr, e)
+		}
+
+		return arr, nil
+	case 5:
+		m := make(map[string]any, min(arg, uint64(len(d.data)-d.pos)))
+
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.isBreak() {
+				break
+			}
+
+			k, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			m[keyString(k)] = v
+		}
+
+		return m, nil
+	case 6:
+		return d.decode(depth + 1)
+	default:
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			return halfToFloat(uint16(arg)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case 27:
+			return math.Float64frombits(arg), nil
+		default:
+			return nil, fmt.Errorf("unsupported simple value %d", arg)
+		}
+	}
+}
+
+func keyString(k any) string {
+	switch t := k.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
@@ -0,0 +1,258 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// MetadataKeyContentType is the metadata key holding the MIME content type of the `Message.Payload`.
+const MetadataKeyContentType = string(types.MessageMetadataKeysContentType)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeMsgPack = "application/msgpack"
+)
+
+// Codec encodes Go values into payloads and decodes payloads into Go values.
+type Codec interface {
+	// ContentType returns the MIME content type of the encoded payloads, e.g. `application/json`.
+	ContentType() string
+	// Marshal encodes _v_.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes _data_ into the pointer _v_.
+	Unmarshal(data []byte, v any) error
+}
+
+// Registry holds `Codec` instances by content type. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs map[string]Codec
+}
+
+// NewRegistry creates a new `Registry` with the _codecs_ registered.
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{codecs: map[string]Codec{}}
+
+	for _, c := range codecs {
+		r.Register(c)
+	}
+
+	return r
+}
+
+// DefaultRegistry has the built-in JSON, CBOR and MessagePack codecs registered. The schema bound `Protobuf`
+// and `Avro` codecs are registered by the application, e.g. `DefaultRegistry.Register(avro)`. Since codecs are
+// registered by content type, use a separate `Registry` per schema when several are needed.
+var DefaultRegistry = NewRegistry(JSON{}, CBOR{}, MsgPack{})
+
+// Register registers (or replaces) the _codec_ for its content type.
+func (r *Registry) Register(codec Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.codecs[normalize(codec.ContentType())] = codec
+}
+
+// Lookup returns the `Codec` for the _contentType_. Parameters such as `charset` are ignored.
+func (r *Registry) Lookup(contentType string) (Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.codecs[normalize(contentType)]
+
+	return c, ok
+}
+
+// Get is like `Lookup` but returns a `types.ErrProtocolMismatch` wrapped error when not found.
+func (r *Registry) Get(contentType string) (Codec, error) {
+	if c, ok := r.Lookup(contentType); ok {
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("%w: no codec for content type %q", types.ErrProtocolMismatch, contentType)
+}
+
+// ContentType returns the content type in the metadata of _payload_ or _def_ if not set.
+func ContentType(payload types.Message, def string) string {
+	if ct, ok := payload.Metadata[MetadataKeyContentType].(string); ok && ct != "" {
+		return ct
+	}
+
+	return def
+}
+
+// Encode encodes _v_ using _codec_ into a new `types.Message` on _topic_ with the content type metadata set.
+func Encode(codec Codec, topic string, v any) (types.Message, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return types.Message{}, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return types.Message{
+		Topic:    topic,
+		Payload:  data,
+		Metadata: map[string]any{MetadataKeyContentType: codec.ContentType()},
+	}, nil
+}
+
+// Decode decodes the payload into _v_ using the codec in _registry_ for the content type of _payload_,
+// or _def_ if the message has no content type.
+//
+// Decode failures are returned as `types.ErrInvalidPayload` wrapped errors.
+func Decode(registry *Registry, payload types.Message, def string, v any) error {
+	c, err := registry.Get(ContentType(payload, def))
+	if err != nil {
+		return err
+	}
+
+	if err := c.Unmarshal(payload.Payload, v); err != nil {
+		return fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return nil
+}
+
+// Transcode decodes _data_ using _from_ and encodes it using _to_.
+//
+// JSON integers are decoded as `int64` (or `uint64`), not `float64`, hence e.g. 64-bit device ids are not
+// rounded when transcoded into a binary codec.
+func Transcode(data []byte, from, to Codec) ([]byte, error) {
+	if normalize(from.ContentType()) == normalize(to.ContentType()) {
+		return data, nil
+	}
+
+	var (
+		v   any
+		err error
+	)
+
+	if normalize(from.ContentType()) == ContentTypeJSON {
+		v, err = decodeJSON(data)
+	} else {
+		err = from.Unmarshal(data, &v)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	out, err := to.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return out, nil
+}
+
+// JSON is the `encoding/json` codec.
+type JSON struct{}
+
+// ContentType returns `application/json`.
+func (JSON) ContentType() string { return ContentTypeJSON }
+
+// Marshal encodes _v_ as JSON.
+func (JSON) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes JSON _data_ into _v_.
+func (JSON) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+func normalize(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// toGeneric converts _v_ into the generic data model (`nil`, `bool`, `int64`, `uint64`, `float64`, `string`,
+// `[]byte`, `[]any` and `map[string]any`) using its JSON representation, hence `json` struct tags are honoured.
+func toGeneric(v any) (any, error) {
+	switch t := v.(type) {
+	case nil, bool, int64, uint64, float64, string, []byte:
+		return t, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSON(data)
+}
+
+// decodeJSON decodes the JSON _data_ into the generic data model, keeping integers as `int64` or `uint64`.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var g any
+	if err := dec.Decode(&g); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return fromJSONNumbers(g), nil
+}
+
+func fromJSONNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u
+		}
+
+		f, _ := t.Float64()
+
+		return f
+	case map[string]any:
+		for k, e := range t {
+			t[k] = fromJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = fromJSONNumbers(e)
+		}
+	}
+
+	return v
+}
+
+// fromGeneric stores the generic value _g_ into the pointer _v_. If _v_ is a `*any` it is set as is,
+// otherwise the value is converted through its JSON representation.
+func fromGeneric(g any, v any) error {
+	if p, ok := v.(*any); ok {
+		*p = g
+		return nil
+	}
+
+	if p, ok := v.(*[]byte); ok {
+		if b, ok := g.([]byte); ok {
+			*p = b
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,103 @@
+package codec_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reading struct {
+	Device string            `json:"device"`
+	Temp   float64           `json:"temp"`
+	Count  int64             `json:"count"`
+	Tags   []string          `json:"tags"`
+	Extra  map[string]string `json:"extra,omitempty"`
+	Valid  bool              `json:"valid"`
+}
+
+func TestCodecs_KnownEncodings(t *testing.T) {
+	v := map[string]any{"a": 1, "b": []int{2, 3}}
+
+	data, err := codec.CBOR{}.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, "a26161016162820203", hex.EncodeToString(data))
+
+	data, err = codec.MsgPack{}.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, "82a16101a162920203", hex.EncodeToString(data))
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	in := reading{Device: "dev-1", Temp: 21.5, Count: -1 << 40, Tags: []string{"a", "b"}, Extra: map[string]string{"k": "v"}, Valid: true}
+
+	for _, c := range []codec.Codec{codec.JSON{}, codec.CBOR{}, codec.MsgPack{}} {
+		data, err := c.Marshal(in)
+		require.NoError(t, err, c.ContentType())
+
+		var out reading
+		require.NoError(t, c.Unmarshal(data, &out), c.ContentType())
+		assert.Equal(t, in, out, c.ContentType())
+
+		var raw []byte
+		data, err = c.Marshal([]byte{1, 2, 3})
+		require.NoError(t, err)
+		require.NoError(t, c.Unmarshal(data, &raw), c.ContentType())
+
+		if c.ContentType() != codec.ContentTypeJSON {
+			assert.Equal(t, []byte{1, 2, 3}, raw, c.ContentType())
+		}
+	}
+}
+
+func TestCodecs_RejectMalformed(t *testing.T) {
+	var v any
+
+	assert.Error(t, codec.CBOR{}.Unmarshal([]byte{0x9a, 0xff, 0xff, 0xff, 0xff}, &v))
+	assert.Error(t, codec.CBOR{}.Unmarshal([]byte{0x01, 0x02}, &v))
+	assert.Error(t, codec.MsgPack{}.Unmarshal([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &v))
+	assert.Error(t, codec.MsgPack{}.Unmarshal([]byte{0xc1}, &v))
+}
+
+func TestTranscodeAndDecode(t *testing.T) {
+	msg, err := codec.Encode(codec.CBOR{}, "devices/1", reading{Device: "dev-1", Temp: 1.5})
+	require.NoError(t, err)
+	assert.Equal(t, codec.ContentTypeCBOR, msg.Metadata[codec.MetadataKeyContentType])
+
+	jsonCodec, err := codec.DefaultRegistry.Get("application/json; charset=utf-8")
+	require.NoError(t, err)
+
+	data, err := codec.Transcode(msg.Payload, codec.CBOR{}, jsonCodec)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"device":"dev-1","temp":1.5,"count":0,"tags":null,"valid":false}`, string(data))
+
+	var out reading
+	require.NoError(t, codec.Decode(codec.DefaultRegistry, msg, codec.ContentTypeJSON, &out))
+	assert.Equal(t, "dev-1", out.Device)
+
+	msg.Metadata[codec.MetadataKeyContentType] = "application/x-protobuf"
+	assert.ErrorIs(t, codec.Decode(codec.DefaultRegistry, msg, "", &out), types.ErrProtocolMismatch)
+
+	msg.Metadata[codec.MetadataKeyContentType] = codec.ContentTypeMsgPack
+	assert.ErrorIs(t, codec.Decode(codec.DefaultRegistry, msg, "", &out), types.ErrInvalidPayload)
+}
+
+func TestTranscode_KeepsLargeIntegers(t *testing.T) {
+	// 2^53 + 1 and the max uint64 cannot be represented by a float64.
+	in := []byte(`{"f":1.5,"id":9007199254740993,"max":18446744073709551615,"neg":-9007199254740993}`)
+
+	for _, c := range []codec.Codec{codec.CBOR{}, codec.MsgPack{}} {
+		data, err := codec.Transcode(in, codec.JSON{}, c)
+		require.NoError(t, err, c.ContentType())
+
+		back, err := codec.Transcode(data, c, codec.JSON{})
+		require.NoError(t, err, c.ContentType())
+		assert.Equal(t, string(in), string(back), c.ContentType())
+	}
+
+	_, err := codec.Transcode([]byte(`{} {}`), codec.JSON{}, codec.CBOR{})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+}
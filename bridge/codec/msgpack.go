@@ -0,0 +1,323 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// MsgPack is a _MessagePack_ codec for the generic data model. Go values are converted in the same manner
+// as for `CBOR`. Extension types are decoded as their raw `[]byte` data.
+type MsgPack struct{}
+
+// ContentType returns `application/msgpack`.
+func (MsgPack) ContentType() string { return ContentTypeMsgPack }
+
+// Marshal encodes _v_ as MessagePack.
+func (MsgPack) Marshal(v any) ([]byte, error) {
+	g, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendMsgPack(nil, g)
+}
+
+// Unmarshal decodes the MessagePack _data_ into _v_.
+func (MsgPack) Unmarshal(data []byte, v any) error {
+	d := &msgpackDecoder{data: data}
+
+	g, err := d.decode(0)
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+
+	if d.pos != len(data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(data)-d.pos)
+	}
+
+	return fromGeneric(g, v)
+}
+
+func appendMsgPackLen(b []byte, n int, fix byte, fixMax int, c8, c16, c32 byte) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case c8 != 0 && n <= math.MaxUint8:
+		return append(b, c8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, c16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, c32), uint32(n))
+	}
+}
+
+func appendMsgPack(b []byte, v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if t {
+			return append(b, 0xc3), nil
+		}
+
+		return append(b, 0xc2), nil
+	case int64:
+		switch {
+		case t >= 0:
+			return appendMsgPack(b, uint64(t))
+		case t >= -32:
+			return append(b, byte(t)), nil
+		case t >= math.MinInt8:
+			return append(b, 0xd0, byte(t)), nil
+		case t >= math.MinInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(t)), nil
+		case t >= math.MinInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(t)), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(t)), nil
+		}
+	case uint64:
+		switch {
+		case t <= 0x7f:
+			return append(b, byte(t)), nil
+		case t <= math.MaxUint8:
+			return append(b, 0xcc, byte(t)), nil
+		case t <= math.MaxUint16:
+			return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(t)), nil
+		case t <= math.MaxUint32:
+			return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(t)), nil
+		default:
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), t), nil
+		}
+	case float64:
+		if t == math.Trunc(t) && t >= math.MinInt64 && t < math.MaxInt64 && !math.IsInf(t, 0) {
+			return appendMsgPack(b, int64(t))
+		}
+
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(t)), nil
+	case string:
+		return append(appendMsgPackLen(b, len(t), 0xa0, 31, 0xd9, 0xda, 0xdb), t...), nil
+	case []byte:
+		return append(appendMsgPackLen(b, len(t), 0xc4, -1, 0xc4, 0xc5, 0xc6), t...), nil
+	case []any:
+		b = appendMsgPackLen(b, len(t), 0x90, 15, 0, 0xdc, 0xdd)
+
+		for _, e := range t {
+			var err error
+			if b, err = appendMsgPack(b, e); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	case map[string]any:
+		b = appendMsgPackLen(b, len(t), 0x80, 15, 0, 0xde, 0xdf)
+
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			b = append(appendMsgPackLen(b, len(k), 0xa0, 31, 0xd9, 0xda, 0xdb), k...)
+
+			var err error
+			if b, err = appendMsgPack(b, t[k]); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errTruncated
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+// uint reads a big endian unsigned integer of _n_ bytes.
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *msgpackDecoder) decode(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("maximum nesting depth exceeded")
+	}
+
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return d.object(int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil || u > math.MaxInt64 {
+			return u, err
+		}
+
+		return int64(u), nil
+	case 0xd0:
+		u, err := d.uint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.uint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.uint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.uint(8)
+		return int64(u), err
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.str(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := d.read(int(n))
+
+		return slices.Clone(raw), err
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.array(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.object(int(n), depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		raw, err := d.read(1 + 1<<(c-0xd4))
+		if err != nil {
+			return nil, err
+		}
+
+		return slices.Clone(raw[1:]), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := d.read(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return slices.Clone(raw[1:]), nil
+	default:
+		return nil, fmt.Errorf("invalid format byte 0x%02x", c)
+	}
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n, depth int) (any, error) {
+	arr := make([]any, 0, min(n, len(d.data)-d.pos))
+
+	for range n {
+		e, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		arr = append(arr, e)
+	}
+
+	return arr, nil
+}
+
+func (d *msgpackDecoder) object(n, depth int) (any, error) {
+	m := make(map[string]any, min(n, len(d.data)-d.pos))
+
+	for range n {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		m[keyString(k)] = v
+	}
+
+	return m, nil
+}
@@ -0,0 +1,113 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ContentTypeProtobuf is the content type of _Protobuf_ binary encoded payloads.
+const ContentTypeProtobuf = "application/x-protobuf"
+
+// Protobuf is a codec that encodes values as a single _Protobuf_ message type, resolved from a descriptor set
+// (e.g. `protoc --include_imports --descriptor_set_out=telemetry.pb telemetry.proto`), hence no generated code
+// is needed.
+//
+// Generated `proto.Message` values are encoded and decoded directly. All other values, such as the generic data
+// model used by `Transcode`, are converted through their _Protobuf_ JSON mapping using a `dynamicpb.Message`.
+type Protobuf struct {
+	desc protoreflect.MessageDescriptor
+}
+
+// NewProtobuf creates a new `Protobuf` codec for the message _desc_.
+func NewProtobuf(desc protoreflect.MessageDescriptor) *Protobuf {
+	return &Protobuf{desc: desc}
+}
+
+// NewProtobufFromDescriptorSet creates a new `Protobuf` codec for the fully qualified _messageName_
+// (e.g. `telemetry.v1.Reading`) in the serialized `descriptorpb.FileDescriptorSet` _data_.
+func NewProtobufFromDescriptorSet(data []byte, messageName string) (*Protobuf, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("protobuf: invalid descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: invalid descriptor set: %w", err)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: message %q: %w", messageName, err)
+	}
+
+	desc, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %q is not a message", messageName)
+	}
+
+	return NewProtobuf(desc), nil
+}
+
+// LoadProtobuf is like `NewProtobufFromDescriptorSet` but reads the descriptor set from the local file _path_.
+func LoadProtobuf(path, messageName string) (*Protobuf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("protobuf: failed to read descriptor set: %w", err)
+	}
+
+	return NewProtobufFromDescriptorSet(data, messageName)
+}
+
+// Descriptor returns the descriptor of the message type.
+func (p *Protobuf) Descriptor() protoreflect.MessageDescriptor { return p.desc }
+
+// ContentType returns `application/x-protobuf`.
+func (p *Protobuf) ContentType() string { return ContentTypeProtobuf }
+
+// Marshal encodes _v_ as the message type.
+func (p *Protobuf) Marshal(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := dynamicpb.NewMessage(p.desc)
+	if err := protojson.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes _data_ into _v_. Fields are named as in the _.proto_ file when not decoding into a
+// `proto.Message`.
+func (p *Protobuf) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	m := dynamicpb.NewMessage(p.desc)
+	if err := proto.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("protobuf: %w", err)
+	}
+
+	js, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("protobuf: %w", err)
+	}
+
+	return json.Unmarshal(js, v)
+}
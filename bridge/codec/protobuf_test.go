@@ -0,0 +1,88 @@
+package codec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// descriptorSet writes the descriptor set of `telemetry.v1.Reading`, as `protoc --descriptor_set_out` would.
+func descriptorSet(t *testing.T) string {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name: proto.String(name), JsonName: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum(),
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("telemetry.proto"),
+		Package: proto.String("telemetry.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Reading"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("device", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("temp", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+				field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+			},
+		}},
+	}}}
+
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "telemetry.pb")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func TestProtobuf_DescriptorSet(t *testing.T) {
+	pb, err := codec.LoadProtobuf(descriptorSet(t), "telemetry.v1.Reading")
+	require.NoError(t, err)
+
+	data, err := pb.Marshal(map[string]any{"device": "dev-1", "temp": 21.5, "tags": []string{"a"}})
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, pb.Unmarshal(data, &out))
+	assert.Equal(t, map[string]any{"device": "dev-1", "temp": 21.5, "tags": []any{"a"}}, out)
+
+	// Decodes into a `proto.Message` as well.
+	m := dynamicpb.NewMessage(pb.Descriptor())
+	require.NoError(t, pb.Unmarshal(data, m))
+	assert.Equal(t, "dev-1", m.Get(pb.Descriptor().Fields().ByName("device")).String())
+
+	// Bridging JSON from the backends to Protobuf devices.
+	js, err := codec.Transcode(data, pb, codec.JSON{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"device":"dev-1","temp":21.5,"tags":["a"]}`, string(js))
+
+	back, err := codec.Transcode(js, codec.JSON{}, pb)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(m, decode(t, pb, back)))
+
+	_, err = pb.Marshal(map[string]any{"unknown": 1})
+	assert.Error(t, err)
+
+	_, err = codec.LoadProtobuf(descriptorSet(t), "telemetry.v1.Missing")
+	assert.Error(t, err)
+}
+
+func decode(t *testing.T, pb *codec.Protobuf, data []byte) *dynamicpb.Message {
+	t.Helper()
+
+	m := dynamicpb.NewMessage(pb.Descriptor())
+	require.NoError(t, pb.Unmarshal(data, m))
+
+	return m
+}
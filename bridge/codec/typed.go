@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"context"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// TypedPublisher publishes values of type _T_ by encoding them with a `Codec` before handing them to
+// the underlying `types.Publisher`.
+type TypedPublisher[T any] struct {
+	publisher types.Publisher
+	codec     Codec
+}
+
+// NewTypedPublisher creates a new `TypedPublisher` that encodes using _codec_ and publishes on _publisher_.
+//
+// If _codec_ is `nil`, the `JSON` codec is used.
+func NewTypedPublisher[T any](publisher types.Publisher, codec Codec) *TypedPublisher[T] {
+	if codec == nil {
+		codec = JSON{}
+	}
+
+	return &TypedPublisher[T]{publisher: publisher, codec: codec}
+}
+
+// Publish encodes _v_ and publishes it on _topic_ with the content type metadata set.
+//
+// The optional _metadata_ is merged into the message metadata. Encode failures are returned as
+// `types.ErrInvalidPayload` wrapped errors.
+func (p *TypedPublisher[T]) Publish(ctx context.Context, topic string, v T, metadata ...map[string]any) error {
+	msg, err := Encode(p.codec, topic, v)
+	if err != nil {
+		return err
+	}
+
+	for _, md := range metadata {
+		maps.Copy(msg.Metadata, md)
+	}
+
+	return p.publisher.Publish(ctx, topic, msg)
+}
+
+// TypedHandler processes a decoded value of type _T_ together with the original _payload_.
+type TypedHandler[T any] func(ctx context.Context, topic string, v T, payload types.Message) error
+
+// TypedOptions configures a `TypedSubscriber`.
+type TypedOptions struct {
+	// Registry is used to look up the codec. If `nil`, `DefaultRegistry` is used.
+	Registry *Registry
+	// Default is the content type assumed for messages without the `MetadataKeyContentType` metadata.
+	// If empty, `ContentTypeJSON` is used.
+	Default string
+}
+
+// TypedSubscriber is a `types.Subscriber` that decodes the payload into a _T_ before invoking its `TypedHandler`.
+type TypedSubscriber[T any] struct {
+	handler TypedHandler[T]
+	opts    TypedOptions
+}
+
+// NewTypedSubscriber creates a new `TypedSubscriber` that invokes _handler_ with the decoded payloads.
+func NewTypedSubscriber[T any](handler TypedHandler[T], opts ...TypedOptions) *TypedSubscriber[T] {
+	var o TypedOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Registry == nil {
+		o.Registry = DefaultRegistry
+	}
+
+	if o.Default == "" {
+		o.Default = ContentTypeJSON
+	}
+
+	return &TypedSubscriber[T]{handler: handler, opts: o}
+}
+
+// Process decodes the payload, using the codec for its content type, and invokes the handler.
+//
+// Payloads that fail to decode are returned as `types.ErrInvalidPayload` wrapped errors (and the handler is
+// not invoked). Unknown content types are returned as `types.ErrProtocolMismatch`.
+func (s *TypedSubscriber[T]) Process(ctx context.Context, topic string, payload types.Message) error {
+	var v T
+	if err := Decode(s.opts.Registry, payload, s.opts.Default, &v); err != nil {
+		return err
+	}
+
+	return s.handler(ctx, topic, v, payload)
+}
@@ -0,0 +1,43 @@
+package codec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTyped_RoundTrip(t *testing.T) {
+	var (
+		got  reading
+		meta map[string]any
+	)
+
+	sub := codec.NewTypedSubscriber(func(ctx context.Context, topic string, v reading, payload types.Message) error {
+		got, meta = v, payload.Metadata
+		return nil
+	})
+
+	pub := codec.NewTypedPublisher[reading](types.PublisherAdapter(sub.Process), codec.CBOR{})
+
+	require.NoError(t, pub.Publish(context.Background(), "devices/1", reading{Device: "1", Temp: 21.5}, map[string]any{"site": "a"}))
+	assert.Equal(t, reading{Device: "1", Temp: 21.5}, got)
+	assert.Equal(t, codec.ContentTypeCBOR, meta[codec.MetadataKeyContentType])
+	assert.Equal(t, "a", meta["site"])
+}
+
+func TestTypedSubscriber_InvalidPayload(t *testing.T) {
+	called := false
+
+	sub := codec.NewTypedSubscriber(func(ctx context.Context, topic string, v reading, payload types.Message) error {
+		called = true
+		return nil
+	})
+
+	err := sub.Process(context.Background(), "devices/1", types.Message{Payload: []byte(`{"temp":"hot"}`)})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+	assert.False(t, called)
+}
@@ -0,0 +1,413 @@
+package expr
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (n *literal) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+func (n *ident) eval(vars map[string]any) (any, error) {
+	return normalize(vars[n.name]), nil
+}
+
+func (n *member) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	key, err := n.key.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case map[string]any:
+		k, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, got %T", key)
+		}
+
+		return normalize(t[k]), nil
+	case []any:
+		var i int
+
+		switch idx := key.(type) {
+		case int64:
+			i = int(idx)
+		case float64:
+			i = int(idx)
+		default:
+			return nil, fmt.Errorf("array index must be a number, got %T", key)
+		}
+
+		if i < 0 {
+			i += len(t)
+		}
+
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+
+		return normalize(t[i]), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (n *unary) eval(vars map[string]any) (any, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		return !Truthy(v), nil
+	}
+
+	switch t := v.(type) {
+	case int64:
+		if t == math.MinInt64 {
+			return -float64(t), nil
+		}
+
+		return -t, nil
+	case float64:
+		return -t, nil
+	default:
+		return nil, fmt.Errorf("operator - requires a number, got %T", v)
+	}
+}
+
+func (n *binary) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+
+		right, err := n.right.eval(vars)
+
+		return Truthy(right), err
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+
+		right, err := n.right.eval(vars)
+
+		return Truthy(right), err
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "+":
+		if ls, ok := left.(string); ok {
+			return ls + toString(right), nil
+		}
+
+		if rs, ok := right.(string); ok {
+			return toString(left) + rs, nil
+		}
+	}
+
+	return arithmetic(n.op, left, right)
+}
+
+func (n *call) eval(vars map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+
+	for i, a := range n.args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = v
+	}
+
+	v, err := n.fn(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+
+	return normalize(v), nil
+}
+
+// Truthy returns the boolean interpretation of _v_: `nil`, `false`, zero and empty string/collections are `false`.
+func Truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case int64:
+		return t != 0
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	case []any:
+		return len(t) > 0
+	case map[string]any:
+		return len(t) > 0
+	default:
+		return true
+	}
+}
+
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if l, ok := a.(int64); ok {
+		if r, ok := b.(int64); ok {
+			return l == r
+		}
+	}
+
+	if l, ok := toFloat(a); ok {
+		r, ok := toFloat(b)
+		return ok && l == r
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(op string, a, b any) (any, error) {
+	if a == nil || b == nil {
+		return false, nil
+	}
+
+	var c int
+
+	switch l := a.(type) {
+	case int64, float64:
+		if li, ok := l.(int64); ok {
+			if ri, ok := b.(int64); ok {
+				c = cmp.Compare(li, ri)
+				break
+			}
+		}
+
+		lf, _ := toFloat(l)
+
+		rf, ok := toFloat(b)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %T", b)
+		}
+
+		c = cmp.Compare(lf, rf)
+	case string:
+		r, ok := b.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %T", b)
+		}
+
+		c = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("cannot compare %T", a)
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func arithmetic(op string, a, b any) (any, error) {
+	if li, ok := a.(int64); ok {
+		if ri, ok := b.(int64); ok {
+			if v, ok := integerArithmetic(op, li, ri); ok {
+				return v, nil
+			}
+		}
+	}
+
+	l, lok := toFloat(a)
+	r, rok := toFloat(b)
+
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s requires numbers, got %T and %T", op, a, b)
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		return math.Mod(l, r), nil
+	}
+}
+
+// integerArithmetic applies _op_ on the integers _l_ and _r_. It returns `false` if the result is not an
+// integer, overflows or is a division by zero, i.e. it must be done on `float64`.
+func integerArithmetic(op string, l, r int64) (int64, bool) {
+	switch op {
+	case "+":
+		v := l + r
+		return v, (r >= 0) == (v >= l)
+	case "-":
+		v := l - r
+		return v, (r >= 0) == (v <= l)
+	case "*":
+		if l == 0 || r == 0 {
+			return 0, true
+		}
+
+		v := l * r
+
+		return v, v/r == l && !(l == -1 && r == math.MinInt64) && !(r == -1 && l == math.MinInt64)
+	case "/":
+		if r == 0 || l%r != 0 || (l == math.MinInt64 && r == -1) {
+			return 0, false
+		}
+
+		return l / r, true
+	default:
+		if r == 0 {
+			return 0, false
+		}
+
+		if r == -1 {
+			return 0, true
+		}
+
+		return l % r, true
+	}
+}
+
+// toFloat returns the number _v_ as a `float64` and `false` if it is not a number.
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int64:
+		return float64(t), true
+	case float64:
+		return t, true
+	default:
+		return 0, false
+	}
+}
+
+// normalize converts Go values into the value model of the expressions: `nil`, `bool`, `int64`, `float64`,
+// `string`, `[]any` and `map[string]any`. A `json.Number` becomes an `int64` if it is an integer that fits,
+// otherwise a `float64`, a `time.Time` becomes a RFC 3339 string and a `time.Duration` seconds. Other values
+// are returned as is.
+func normalize(v any) any {
+	switch t := v.(type) {
+	case int:
+		return int64(t)
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint:
+		return unsigned(uint64(t))
+	case uint8:
+		return int64(t)
+	case uint16:
+		return int64(t)
+	case uint32:
+		return int64(t)
+	case uint64:
+		return unsigned(t)
+	case float32:
+		return float64(t)
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+
+		return t.String()
+	case []string:
+		out := make([]any, len(t))
+		for i, s := range t {
+			out[i] = s
+		}
+
+		return out
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		return t.Seconds()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return v
+	}
+}
+
+func unsigned(u uint64) any {
+	if u > math.MaxInt64 {
+		return float64(u)
+	}
+
+	return int64(u)
+}
+
+func toString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
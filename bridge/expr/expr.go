@@ -0,0 +1,225 @@
+package expr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Expression is a compiled expression.
+//
+// The expression language supports:
+//
+// - Literals: numbers, strings (single or double quoted), `true`, `false` and `null`. Integers are kept as `int64`,
+// also when decoded from the payload, and arithmetic on integers stays exact unless it overflows or a division
+// has a remainder, then it is done on `float64`.
+//
+// - Variables and paths: `payload.device.id`, `metadata["content-type"]`, `payload.items[0]`. A missing path
+// evaluates to `null`.
+//
+// - Operators: `!`, `-` (unary), `*`, `/`, `%`, `+` (also string concatenation), `-`, `<`, `<=`, `>`, `>=`,
+// `==`, `!=`, `&&` and `||` (short-circuit), and parentheses.
+//
+// - Functions: `len`, `exists`, `lower`, `upper`, `trim`, `string`, `number`, `contains`, `startsWith`,
+// `endsWith`, `coalesce` and `now`.
+//
+// Ordering comparisons involving `null` are `false`.
+type Expression struct {
+	src  string
+	root node
+}
+
+// Compile compiles the expression _src_.
+func Compile(src string) (*Expression, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+
+	return parse(src, tokens)
+}
+
+// parse parses the lexed _tokens_ of the expression _src_.
+func parse(src string, tokens []token) (*Expression, error) {
+	p := &parser{tokens: tokens}
+
+	root, err := p.parseExpr(1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("invalid expression %q: unexpected %q at %d", src, t.text, t.pos)
+	}
+
+	return &Expression{src: src, root: root}, nil
+}
+
+// MustCompile is like `Compile` but panics on error.
+func MustCompile(src string) *Expression {
+	e, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression using the _vars_ as root variables.
+func (e *Expression) Eval(vars map[string]any) (any, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", e.src, err)
+	}
+
+	return v, nil
+}
+
+// EvalBool evaluates the expression and returns its `Truthy` value.
+func (e *Expression) EvalBool(vars map[string]any) (bool, error) {
+	v, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	return Truthy(v), nil
+}
+
+// MessageVars creates the root variables for evaluating expressions over a message:
+//
+// - topic: the concrete topic.
+//
+// - payload: the JSON decoded `Message.Payload` or `null` if empty. Numbers are decoded as `json.Number`,
+// hence large integers (e.g. 64-bit ids) are not rounded when copied into an output payload.
+//
+// - metadata: the `Message.Metadata`.
+//
+// - qos: the QoS level or `null` if not set.
+//
+// If the payload is not valid JSON, a `types.ErrInvalidPayload` wrapped error is returned.
+func MessageVars(topic string, payload types.Message) (map[string]any, error) {
+	vars := map[string]any{
+		"topic":    topic,
+		"payload":  nil,
+		"metadata": map[string]any{},
+		"qos":      nil,
+	}
+
+	if len(payload.Metadata) > 0 {
+		vars["metadata"] = payload.Metadata
+	}
+
+	if payload.Qos != nil {
+		vars["qos"] = payload.Qos.Level
+	}
+
+	if len(payload.Payload) > 0 {
+		var body any
+
+		dec := json.NewDecoder(bytes.NewReader(payload.Payload))
+		dec.UseNumber()
+
+		if err := dec.Decode(&body); err != nil {
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+		}
+
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("%w: unexpected data after the JSON value", types.ErrInvalidPayload)
+		}
+
+		vars["payload"] = body
+	}
+
+	return vars, nil
+}
+
+// Template is a compiled string template where `${expression}` is replaced by the string value of the expression.
+type Template struct {
+	src   string
+	parts []any // string or *Expression
+}
+
+// CompileTemplate compiles the template _src_, e.g. `device-${payload.id}`. Use `$${` for a literal `${`.
+func CompileTemplate(src string) (*Template, error) {
+	t := &Template{src: src}
+
+	rest := src
+	for {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			break
+		}
+
+		if i > 0 && rest[i-1] == '$' {
+			t.parts = append(t.parts, rest[:i-1]+"${")
+			rest = rest[i+2:]
+
+			continue
+		}
+
+		// The lexer finds the end since a `}` may be part of a string literal in the expression.
+		body := rest[i+2:]
+
+		tokens, end, err := lexUntil(body, '}')
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %w", src, err)
+		}
+
+		if end < 0 {
+			return nil, fmt.Errorf("invalid template %q: unterminated ${", src)
+		}
+
+		e, err := parse(body[:end], tokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %w", src, err)
+		}
+
+		if i > 0 {
+			t.parts = append(t.parts, rest[:i])
+		}
+
+		t.parts = append(t.parts, e)
+		rest = body[end+1:]
+	}
+
+	if rest != "" {
+		t.parts = append(t.parts, rest)
+	}
+
+	return t, nil
+}
+
+// String returns the source of the template.
+func (t *Template) String() string {
+	return t.src
+}
+
+// Render renders the template using the _vars_ as root variables.
+func (t *Template) Render(vars map[string]any) (string, error) {
+	var sb strings.Builder
+
+	for _, p := range t.parts {
+		switch v := p.(type) {
+		case string:
+			sb.WriteString(v)
+		case *Expression:
+			value, err := v.Eval(vars)
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(toString(value))
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,115 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/expr"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	vars, err := expr.MessageVars("devices/1/telemetry", types.Message{
+		Payload:  []byte(`{"temp": 42.5, "tags": ["a", "b"], "device": {"id": "dev-1"}}`),
+		Metadata: map[string]any{"region": "eu", "content-type": "application/json", "count": 3},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`payload.temp > 40 && metadata.region == "eu"`, true},
+		{`payload.temp > 40 && metadata.region == 'us'`, false},
+		{`payload.missing > 40`, false},
+		{`!exists(payload.missing) || false`, true},
+		{`metadata["content-type"]`, "application/json"},
+		{`metadata.count * 2 + 1`, int64(7)},
+		{`7 / 2`, 3.5},
+		{`payload.temp * 2`, 85.0},
+		{`payload.temp > 42`, true},
+		{`1 == 1.0`, true},
+		{`payload.tags[1]`, "b"},
+		{`payload.tags[-1]`, "b"},
+		{`len(payload.tags)`, int64(2)},
+		{`"id-" + payload.device.id`, "id-dev-1"},
+		{`upper(payload.device.id)`, "DEV-1"},
+		{`startsWith(topic, "devices/")`, true},
+		{`(1 + 2) * 3 == 9`, true},
+		{`coalesce(payload.none, metadata.region)`, "eu"},
+	}
+
+	for _, tc := range tests {
+		e, err := expr.Compile(tc.src)
+		require.NoError(t, err, tc.src)
+
+		got, err := e.Eval(vars)
+		require.NoError(t, err, tc.src)
+		assert.Equal(t, tc.want, got, tc.src)
+	}
+}
+
+func TestExpression_Errors(t *testing.T) {
+	for _, src := range []string{`payload.`, `1 +`, `foo(1)`, `"unterminated`, `a b`, `(1`} {
+		_, err := expr.Compile(src)
+		assert.Error(t, err, src)
+	}
+
+	_, err := expr.MustCompile(`"a" < 1`).Eval(nil)
+	assert.Error(t, err)
+
+	_, err = expr.MessageVars("a", types.Message{Payload: []byte("not json")})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+}
+
+func TestTemplate_Render(t *testing.T) {
+	tmpl, err := expr.CompileTemplate("device-${payload.id}/${topic} $${literal}")
+	require.NoError(t, err)
+
+	got, err := tmpl.Render(map[string]any{"topic": "t", "payload": map[string]any{"id": 7}})
+	require.NoError(t, err)
+	assert.Equal(t, "device-7/t ${literal}", got)
+}
+
+func TestExpression_LargeIntegers(t *testing.T) {
+	// 2^53 + 1 cannot be represented by a float64.
+	vars, err := expr.MessageVars("a", types.Message{Payload: []byte(`{"id": 9007199254740993, "big": 18446744073709551615}`)})
+	require.NoError(t, err)
+
+	for src, want := range map[string]any{
+		`payload.id`:                         int64(9007199254740993),
+		`payload.id + 1`:                     int64(9007199254740994),
+		`payload.id == 9007199254740993`:     true,
+		`payload.id > 9007199254740992`:      true,
+		`string(payload.id)`:                 "9007199254740993",
+		`9223372036854775807 + 1 > 0`:        true,
+		`payload.big > 9223372036854775807`:  true,
+		`number("9007199254740993")`:         int64(9007199254740993),
+		`-payload.id`:                        int64(-9007199254740993),
+		`payload.id % 10`:                    int64(3),
+		`payload.id / 9007199254740993 == 1`: true,
+		`payload.id * 1000 > payload.id`:     true,
+		`-9223372036854775807 - 2 < 0`:       true,
+		`len("abc") * 3`:                     int64(9),
+	} {
+		got, err := expr.MustCompile(src).Eval(vars)
+		require.NoError(t, err, src)
+		assert.Equal(t, want, got, src)
+	}
+
+	_, err = expr.MessageVars("a", types.Message{Payload: []byte(`{} {}`)})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+}
+
+func TestTemplate_BraceInExpression(t *testing.T) {
+	tmpl, err := expr.CompileTemplate(`${payload.x == "}"}-${"{" + payload.x + "}"}`)
+	require.NoError(t, err)
+
+	got, err := tmpl.Render(map[string]any{"payload": map[string]any{"x": "}"}})
+	require.NoError(t, err)
+	assert.Equal(t, "true-{}}", got)
+
+	_, err = expr.CompileTemplate(`${payload.x == "}"`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Func is a function callable from an expression.
+type Func func(args ...any) (any, error)
+
+// functions are the built-in functions available in expressions.
+var functions = map[string]Func{
+	"len": func(args ...any) (any, error) {
+		if err := arity(args, 1); err != nil {
+			return nil, err
+		}
+
+		switch t := args[0].(type) {
+		case nil:
+			return int64(0), nil
+		case string:
+			return int64(len(t)), nil
+		case []any:
+			return int64(len(t)), nil
+		case map[string]any:
+			return int64(len(t)), nil
+		default:
+			return nil, fmt.Errorf("unsupported type %T", t)
+		}
+	},
+	"exists": func(args ...any) (any, error) {
+		if err := arity(args, 1); err != nil {
+			return nil, err
+		}
+
+		return args[0] != nil, nil
+	},
+	"lower": stringFunc(strings.ToLower),
+	"upper": stringFunc(strings.ToUpper),
+	"trim":  stringFunc(strings.TrimSpace),
+	"string": func(args ...any) (any, error) {
+		if err := arity(args, 1); err != nil {
+			return nil, err
+		}
+
+		return toString(args[0]), nil
+	},
+	"number": func(args ...any) (any, error) {
+		if err := arity(args, 1); err != nil {
+			return nil, err
+		}
+
+		switch t := args[0].(type) {
+		case int64, float64:
+			return t, nil
+		case bool:
+			if t {
+				return int64(1), nil
+			}
+
+			return int64(0), nil
+		case string:
+			return parseNumber(strings.TrimSpace(t))
+		default:
+			return nil, fmt.Errorf("cannot convert %T to number", t)
+		}
+	},
+	"contains":   stringPredicate(strings.Contains),
+	"startsWith": stringPredicate(strings.HasPrefix),
+	"endsWith":   stringPredicate(strings.HasSuffix),
+	"now": func(args ...any) (any, error) {
+		if err := arity(args, 0); err != nil {
+			return nil, err
+		}
+
+		return time.Now().UTC().Format(time.RFC3339Nano), nil
+	},
+	"coalesce": func(args ...any) (any, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+
+		return nil, nil
+	},
+}
+
+func arity(args []any, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d argument(s), got %d", n, len(args))
+	}
+
+	return nil
+}
+
+func stringFunc(fn func(string) string) Func {
+	return func(args ...any) (any, error) {
+		if err := arity(args, 1); err != nil {
+			return nil, err
+		}
+
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		return fn(toString(args[0])), nil
+	}
+}
+
+func stringPredicate(fn func(s, substr string) bool) Func {
+	return func(args ...any) (any, error) {
+		if err := arity(args, 2); err != nil {
+			return nil, err
+		}
+
+		s, ok := args[0].(string)
+		if !ok {
+			return false, nil
+		}
+
+		return fn(s, toString(args[1])), nil
+	}
+}
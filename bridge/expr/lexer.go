@@ -0,0 +1,128 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// num is the `int64` or `float64` value of a number.
+	num any
+	pos int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ","}
+
+func lex(src string) ([]token, error) {
+	tokens, _, err := lexUntil(src, 0)
+	return tokens, err
+}
+
+// lexUntil lexes _src_ until the _stop_ character is found outside a string literal. It returns the tokens and
+// the position of _stop_, or -1 if not found (or _stop_ is zero).
+func lexUntil(src string, stop byte) ([]token, int, error) {
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+
+		switch {
+		case stop != 0 && src[i] == stop:
+			return append(tokens, token{kind: tokEOF, pos: i}), i, nil
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+
+			num, err := parseNumber(src[start:i])
+			if err != nil {
+				return nil, -1, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+			}
+
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: num, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+
+			var sb strings.Builder
+
+			for ; i < len(src) && rune(src[i]) != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[i])
+					}
+
+					continue
+				}
+
+				sb.WriteByte(src[i])
+			}
+
+			if i >= len(src) {
+				return nil, -1, fmt.Errorf("unterminated string at %d", start)
+			}
+
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		default:
+			matched := false
+
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				return nil, -1, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), -1, nil
+}
+
+// parseNumber parses _s_ as an `int64` if it is an integer that fits, otherwise as a `float64`.
+func parseNumber(s string) (any, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,221 @@
+package expr
+
+import (
+	"fmt"
+)
+
+// node is a node in the expression syntax tree.
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type (
+	literal struct{ value any }
+	ident   struct{ name string }
+	member  struct{ target, key node }
+	unary   struct {
+		op      string
+		operand node
+	}
+	binary struct {
+		op          string
+		left, right node
+	}
+	call struct {
+		name string
+		fn   Func
+		args []node
+	}
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// precedence of the binary operators, higher binds tighter.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expectOp(op string) error {
+	if t := p.next(); t.kind != tokOp || t.text != op {
+		return fmt.Errorf("expected %q at %d", op, t.pos)
+	}
+
+	return nil
+}
+
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+
+		p.next()
+
+		right, err := p.parseExpr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); t.kind == tokOp && (t.text == "!" || t.text == "-") {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unary{op: t.text, operand: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokOp {
+			return n, nil
+		}
+
+		switch t.text {
+		case ".":
+			p.next()
+
+			key := p.next()
+			if key.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name at %d", key.pos)
+			}
+
+			n = &member{target: n, key: &literal{value: key.text}}
+		case "[":
+			p.next()
+
+			key, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+
+			n = &member{target: n, key: key}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		return &literal{value: t.num}, nil
+	case tokString:
+		return &literal{value: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null", "nil":
+			return &literal{value: nil}, nil
+		}
+
+		if n := p.peek(); n.kind == tokOp && n.text == "(" {
+			return p.parseCall(t)
+		}
+
+		return &ident{name: t.text}, nil
+	case tokOp:
+		if t.text == "(" {
+			n, err := p.parseExpr(1)
+			if err != nil {
+				return nil, err
+			}
+
+			return n, p.expectOp(")")
+		}
+	}
+
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+
+	p.next() // (
+
+	c := &call{name: name.text, fn: fn}
+
+	if t := p.peek(); t.kind == tokOp && t.text == ")" {
+		p.next()
+		return c, nil
+	}
+
+	for {
+		arg, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+
+		c.args = append(c.args, arg)
+
+		t := p.next()
+		if t.kind == tokOp && t.text == ")" {
+			return c, nil
+		}
+
+		if t.kind != tokOp || t.text != "," {
+			return nil, fmt.Errorf("expected ',' or ')' at %d", t.pos)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// Prometheus is an _OpenTelemetry_ `metric.MeterProvider` that exposes the recorded metrics, e.g. by the metrics
+// middlewares, in the _Prometheus_ text exposition format.
+//
+// The metrics are held in a private registry, hence several instances do not interfere with each other nor
+// with the global _Prometheus_ registry. Use `Shutdown` to release it.
+type Prometheus struct {
+	*sdkmetric.MeterProvider
+	registry *prometheus.Registry
+}
+
+// NewPrometheus creates a new `Prometheus` meter provider. The _opts_ are passed on to the underlying
+// `sdkmetric.MeterProvider`, e.g. to add a `sdkmetric.WithResource` or views.
+func NewPrometheus(opts ...sdkmetric.Option) (*Prometheus, error) {
+	registry := prometheus.NewRegistry()
+
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("metrics: failed to create prometheus exporter: %w", err)
+	}
+
+	return &Prometheus{
+		MeterProvider: sdkmetric.NewMeterProvider(append(opts, sdkmetric.WithReader(exporter))...),
+		registry:      registry,
+	}, nil
+}
+
+// Handler returns a `http.Handler` that exposes the metrics in the _Prometheus_ text exposition format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
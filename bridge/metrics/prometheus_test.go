@@ -0,0 +1,32 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func TestPrometheus_TextExposition(t *testing.T) {
+	provider, err := metrics.NewPrometheus()
+	require.NoError(t, err)
+
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	counter, err := provider.Meter("test").Int64Counter("bridge.test.messages", metric.WithUnit("{message}"))
+	require.NoError(t, err)
+
+	counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("topic", `a"b`)))
+
+	rec := httptest.NewRecorder()
+	provider.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), "# TYPE bridge_test_messages_total counter")
+	assert.Contains(t, rec.Body.String(), `bridge_test_messages_total{otel_scope_name="test",otel_scope_schema_url="",otel_scope_version="",topic="a\"b"} 2`)
+}
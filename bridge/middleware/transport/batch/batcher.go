@@ -0,0 +1,255 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// DefaultLinger is the default max time a message waits for its batch to fill up.
+const DefaultLinger = 10 * time.Millisecond
+
+// Options configures the `Batcher`.
+type Options struct {
+	// MaxCount is the max number of messages in a batch. If zero or less, 10 is used (the SQS limit).
+	MaxCount int
+	// MaxBytes is the max accumulated payload size, in bytes, of a batch. If zero, there is no size limit.
+	//
+	// A single message larger than _MaxBytes_ is sent in a batch of its own.
+	MaxBytes int
+	// Linger is the max time the first message of a batch waits before the batch is flushed. If zero,
+	// `DefaultLinger` is used.
+	Linger time.Duration
+	// FlushTimeout bounds the time a batch may take to publish. If zero, the batch is only bounded by the earliest
+	// deadline of the callers in the batch (if any).
+	FlushTimeout time.Duration
+}
+
+type batch struct {
+	// ctx is the context of the first caller, detached from its cancellation, carrying its values (e.g. tracing).
+	ctx context.Context
+	// deadline is the earliest deadline of the callers, zero if none has a deadline.
+	deadline time.Time
+	topic    string
+	payloads []types.Message
+	results  []chan error
+	bytes    int
+	timer    *time.Timer
+}
+
+// Batcher is a `Publisher` that accumulates messages per topic and publishes them as a batch when the
+// count, byte size, or linger time is reached.
+//
+// If the next `Publisher` implements `types.BatchPublisher`, the batch is sent using `PublishBatch`, otherwise
+// the messages are published one by one.
+//
+// Each `Publish` call blocks until its message has been sent and returns the result for that message. If the
+// _ctx_ of the caller is done before the batch is flushed, `Publish` returns `ctx.Err()` but the message may
+// still be published.
+//
+// The batch is published with the context of its first caller, detached from the caller cancellation (since the
+// batch carries messages of other callers) but bounded by the earliest deadline of the callers in the batch and
+// `Options.FlushTimeout`.
+//
+// It implements `io.Closer` and `Close` must be called to flush the pending batches.
+type Batcher struct {
+	next    types.Publisher
+	opts    Options
+	mu      sync.Mutex
+	pending map[string]*batch
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewBatcher creates a new `Batcher` that publishes the batches on _next_.
+func NewBatcher(next types.Publisher, opts Options) *Batcher {
+	if opts.MaxCount <= 0 {
+		opts.MaxCount = 10
+	}
+
+	if opts.Linger <= 0 {
+		opts.Linger = DefaultLinger
+	}
+
+	return &Batcher{next: next, opts: opts, pending: map[string]*batch{}}
+}
+
+// Batching creates a `PublisherMiddleware` that wraps the next `Publisher` in a `Batcher`.
+//
+// NOTE: `types.BatchPublisher` is only detected on the immediate next `Publisher`, hence `Batching` must be
+// the last middleware before the transport. Any middleware in between hides it and the messages are published
+// one by one.
+//
+// The created batchers are passed to _created_ (if not `nil`) so they can be closed at shutdown.
+func Batching(opts Options, created func(b *Batcher)) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		b := NewBatcher(next, opts)
+
+		if created != nil {
+			created(b)
+		}
+
+		return b
+	}
+}
+
+// Publish adds the message to the batch of _topic_ and waits for the batch to be published.
+//
+// If the `Batcher` is closed, `types.ErrServerNotConnected` is returned.
+func (b *Batcher) Publish(ctx context.Context, topic string, payload types.Message) error {
+	result := make(chan error, 1)
+
+	if err := b.add(ctx, topic, payload, result); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush publishes all pending batches without waiting for them to fill up.
+func (b *Batcher) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic := range b.pending {
+		b.flush(topic)
+	}
+}
+
+// Close flushes the pending batches, waits for all batches to be published and stops accepting new messages.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+
+	b.closed = true
+
+	for topic := range b.pending {
+		b.flush(topic)
+	}
+
+	b.mu.Unlock()
+	b.wg.Wait()
+
+	return nil
+}
+
+func (b *Batcher) add(ctx context.Context, topic string, payload types.Message, result chan error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return types.ErrServerNotConnected
+	}
+
+	size := len(payload.Payload)
+
+	if cur, ok := b.pending[topic]; ok && b.opts.MaxBytes > 0 && cur.bytes+size > b.opts.MaxBytes {
+		b.flush(topic)
+	}
+
+	cur, ok := b.pending[topic]
+	if !ok {
+		cur = &batch{ctx: context.WithoutCancel(ctx), topic: topic}
+		cur.timer = time.AfterFunc(b.opts.Linger, func() { b.expire(cur) })
+		b.pending[topic] = cur
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && (cur.deadline.IsZero() || deadline.Before(cur.deadline)) {
+		cur.deadline = deadline
+	}
+
+	cur.payloads = append(cur.payloads, payload)
+	cur.results = append(cur.results, result)
+	cur.bytes += size
+
+	if len(cur.payloads) >= b.opts.MaxCount || (b.opts.MaxBytes > 0 && cur.bytes >= b.opts.MaxBytes) {
+		b.flush(topic)
+	}
+
+	return nil
+}
+
+// expire is invoked when the linger time of _cur_ has elapsed.
+func (b *Batcher) expire(cur *batch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The batch may already have been flushed due to count or size.
+	if b.pending[cur.topic] == cur {
+		b.flush(cur.topic)
+	}
+}
+
+// flush removes the pending batch of _topic_ and publishes it asynchronously, the lock must be held.
+func (b *Batcher) flush(topic string) {
+	cur := b.pending[topic]
+	delete(b.pending, topic)
+
+	cur.timer.Stop()
+
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		for i, err := range b.publish(cur) {
+			cur.results[i] <- err
+		}
+	}()
+}
+
+func (b *Batcher) publish(cur *batch) []error {
+	ctx := cur.ctx
+
+	if !cur.deadline.IsZero() {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, cur.deadline)
+		defer cancel()
+	}
+
+	if b.opts.FlushTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, b.opts.FlushTimeout)
+		defer cancel()
+	}
+
+	if bp, ok := b.next.(types.BatchPublisher); ok {
+		errs := bp.PublishBatch(ctx, cur.topic, cur.payloads)
+		if len(errs) == len(cur.payloads) {
+			return errs
+		}
+
+		// Never report success for a message without a result.
+		results := make([]error, len(cur.payloads))
+		for i := range results {
+			if i < len(errs) {
+				results[i] = errs[i]
+			} else {
+				results[i] = fmt.Errorf("%w: no batch result for message %d", types.ErrProtocolMismatch, i)
+			}
+		}
+
+		return results
+	}
+
+	errs := make([]error, len(cur.payloads))
+	for i, payload := range cur.payloads {
+		errs[i] = b.next.Publish(ctx, cur.topic, payload)
+	}
+
+	return errs
+}
@@ -0,0 +1,161 @@
+package batch_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/batch"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchPublisher struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (p *batchPublisher) Publish(ctx context.Context, topic string, payload types.Message) error {
+	return fmt.Errorf("unexpected single publish")
+}
+
+func (p *batchPublisher) PublishBatch(ctx context.Context, topic string, payloads []types.Message) []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	errs := make([]error, len(payloads))
+	batch := make([]string, len(payloads))
+
+	for i, payload := range payloads {
+		batch[i] = string(payload.Payload)
+
+		if batch[i] == "bad" {
+			errs[i] = types.ErrInvalidPayload
+		}
+	}
+
+	p.batches = append(p.batches, batch)
+
+	return errs
+}
+
+func publishAll(b types.Publisher, payloads ...string) []error {
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(payloads))
+
+	for i, payload := range payloads {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs[i] = b.Publish(context.Background(), "orders", types.Message{Payload: []byte(payload)})
+		}()
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+func TestBatcher_FlushOnCountWithPerMessageResults(t *testing.T) {
+	next := &batchPublisher{}
+	b := batch.NewBatcher(next, batch.Options{MaxCount: 3, Linger: time.Hour})
+
+	errs := publishAll(b, "a", "bad", "c")
+
+	require.Len(t, next.batches, 1)
+	assert.ElementsMatch(t, []string{"a", "bad", "c"}, next.batches[0])
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], types.ErrInvalidPayload)
+	assert.NoError(t, errs[2])
+
+	require.NoError(t, b.Close())
+	assert.ErrorIs(t, b.Publish(context.Background(), "orders", types.Message{}), types.ErrServerNotConnected)
+}
+
+func TestBatcher_FlushOnBytesAndLinger(t *testing.T) {
+	next := &batchPublisher{}
+	b := batch.NewBatcher(next, batch.Options{MaxCount: 100, MaxBytes: 8, Linger: 20 * time.Millisecond})
+
+	start := time.Now()
+	require.NoError(t, b.Publish(context.Background(), "orders", types.Message{Payload: []byte("abc")}))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	require.NoError(t, b.Publish(context.Background(), "orders", types.Message{Payload: []byte("0123456789")}))
+
+	assert.Equal(t, [][]string{{"abc"}, {"0123456789"}}, next.batches)
+	require.NoError(t, b.Close())
+}
+
+func TestBatcher_FallbackToPublish(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		count int
+	)
+
+	b := batch.NewBatcher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		count++
+
+		return nil
+	}), batch.Options{MaxCount: 2})
+
+	for _, err := range publishAll(b, "a", "b", "c", "d", "e") {
+		assert.NoError(t, err)
+	}
+
+	require.NoError(t, b.Close())
+	assert.Equal(t, 5, count)
+}
+
+type ctxKey struct{}
+
+func TestBatcher_PublishContext(t *testing.T) {
+	var (
+		value    any
+		deadline time.Time
+		err      error
+	)
+
+	b := batch.NewBatcher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		value = ctx.Value(ctxKey{})
+		deadline, _ = ctx.Deadline()
+		err = ctx.Err()
+
+		return nil
+	}), batch.Options{MaxCount: 2, Linger: time.Hour})
+
+	// The first caller gives up before the batch is flushed.
+	first, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "trace"), time.Hour)
+	cancel()
+
+	assert.ErrorIs(t, b.Publish(first, "orders", types.Message{}), context.Canceled)
+
+	second, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	require.NoError(t, b.Publish(second, "orders", types.Message{}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "trace", value)
+
+	expected, _ := second.Deadline()
+	assert.Equal(t, expected, deadline)
+
+	require.NoError(t, b.Close())
+
+	// Bounded by the flush timeout when no caller has a deadline.
+	b = batch.NewBatcher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), batch.Options{MaxCount: 1, FlushTimeout: 10 * time.Millisecond})
+
+	assert.ErrorIs(t, b.Publish(context.Background(), "orders", types.Message{}), context.DeadlineExceeded)
+	require.NoError(t, b.Close())
+}
@@ -0,0 +1,30 @@
+package capability
+
+import (
+	"context"
+
+	"github.com/mariotoffia/gobridge/bridge/capability"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the capability validation middleware.
+type Options struct {
+	// Connection is the `types.Connection` the messages are published on.
+	Connection types.Connection
+}
+
+// PublishValidation creates a `PublisherMiddleware` that rejects messages the `Options.Connection` cannot publish on
+// the topic (see `capability.ValidateMessage`), before they reach the transport.
+//
+// The capabilities are queried on each publish since they may change when the connection is re-configured.
+func PublishValidation(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := capability.ValidateMessage(capability.For(opts.Connection, topic), topic, payload); err != nil {
+				return err
+			}
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package capability_test
+
+import (
+	"context"
+	"testing"
+
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/capability"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mqttConnection struct{}
+
+func (mqttConnection) Close() error                                        { return nil }
+func (mqttConnection) GetID() string                                       { return "mqtt" }
+func (mqttConnection) GetTransportType() types.TransportType               { return types.TransportTypeMQTT }
+func (mqttConnection) Start(context.Context, types.ConnectionConfig) error { return nil }
+func (mqttConnection) Capabilities(...string) map[string]types.Capabilities {
+	return map[string]types.Capabilities{"": {types.QoSLevels(0, 1), types.MaxPayloadSize(16)}}
+}
+
+func TestPublishValidation(t *testing.T) {
+	published := 0
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		published++
+		return nil
+	}), mw.PublishValidation(mw.Options{Connection: mqttConnection{}}))
+
+	assert.NoError(t, pub.Publish(context.Background(), "a", types.Message{Payload: []byte("ok"), Qos: &types.QosLevel{Level: 1}}))
+	assert.ErrorIs(t, pub.Publish(context.Background(), "a", types.Message{Qos: &types.QosLevel{Level: 2}}), types.ErrQoSNotSupported)
+	assert.ErrorIs(t, pub.Publish(context.Background(), "a", types.Message{Payload: make([]byte, 17)}), types.ErrPayloadTooLarge)
+	assert.Equal(t, 1, published)
+}
@@ -0,0 +1,165 @@
+package chunk_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/chunk"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func split(t *testing.T, size int, payload types.Message) []types.Message {
+	var chunks []types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		chunks = append(chunks, payload)
+		return nil
+	}), chunk.PublishChunked(chunk.SplitOptions{ChunkSize: size}))
+
+	require.NoError(t, pub.Publish(context.Background(), "firmware", payload))
+
+	return chunks
+}
+
+func TestChunk_RoundTripOutOfOrder(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+	chunks := split(t, 128, types.Message{Payload: payload, Metadata: map[string]any{"device": "1"}})
+
+	require.Len(t, chunks, 8)
+	assert.Equal(t, 7, chunks[7].Metadata[chunk.MetadataKeyIndex])
+	assert.Len(t, chunks[7].Payload, 1000-7*128)
+
+	var got []types.Message
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = append(got, payload)
+		return nil
+	}), chunk.SubscriberReassemble(chunk.ReassembleOptions{}))
+
+	slices.Reverse(chunks)
+
+	for i, c := range chunks {
+		require.NoError(t, sub.Process(context.Background(), "firmware", c))
+
+		if i == 2 {
+			// Re-delivered chunk is ignored.
+			require.NoError(t, sub.Process(context.Background(), "firmware", c))
+		}
+	}
+
+	require.Len(t, got, 1)
+	assert.Equal(t, payload, got[0].Payload)
+	assert.Equal(t, map[string]any{"device": "1"}, got[0].Metadata)
+
+	small := split(t, 128, types.Message{Payload: []byte("small")})
+	require.Len(t, small, 1)
+	require.NoError(t, sub.Process(context.Background(), "firmware", small[0]))
+	assert.Equal(t, "small", string(got[1].Payload))
+}
+
+func TestReassembler_Rejects(t *testing.T) {
+	var (
+		dropped   []string
+		delivered int
+	)
+
+	r := chunk.NewReassembler(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		delivered++
+		return nil
+	}), chunk.ReassembleOptions{
+		Timeout:   20 * time.Millisecond,
+		MaxMemory: 3000,
+		OnDropped: func(groupID string, err error) { dropped = append(dropped, groupID) },
+	})
+
+	// Memory limit, a group that can never fit is rejected up front.
+	assert.ErrorIs(t, r.Process(context.Background(), "a", split(t, 100, types.Message{Payload: make([]byte, 4000)})[0]), types.ErrPayloadTooLarge)
+
+	// Memory limit, two groups that fit on their own but not together.
+	chunks := split(t, 100, types.Message{Payload: bytes.Repeat([]byte("x"), 1000)})
+	other := split(t, 100, types.Message{Payload: bytes.Repeat([]byte("y"), 1000)})
+
+	for _, c := range chunks[:9] {
+		require.NoError(t, r.Process(context.Background(), "a", c))
+	}
+
+	var err error
+	for _, c := range other {
+		if err = r.Process(context.Background(), "a", c); err != nil {
+			break
+		}
+	}
+
+	assert.ErrorIs(t, err, types.ErrPayloadTooLarge)
+	assert.Len(t, dropped, 1)
+
+	require.NoError(t, r.Process(context.Background(), "a", chunks[9]))
+	assert.Equal(t, 1, delivered)
+
+	// Timeout
+	require.NoError(t, r.Process(context.Background(), "a", other[0]))
+	time.Sleep(30 * time.Millisecond)
+	r.Prune()
+	assert.Len(t, dropped, 2)
+
+	// Tampered chunk
+	tampered := split(t, 4, types.Message{Payload: []byte("12345678")})
+	tampered[1].Payload = []byte("xxxx")
+
+	require.NoError(t, r.Process(context.Background(), "a", tampered[0]))
+	assert.ErrorIs(t, r.Process(context.Background(), "a", tampered[1]), types.ErrInvalidPayload)
+
+	// Invalid metadata
+	invalid := tampered[0]
+	invalid.Metadata = map[string]any{chunk.MetadataKeyGroupID: "g", chunk.MetadataKeyIndex: "3", chunk.MetadataKeyCount: 2, chunk.MetadataKeySize: 8}
+	assert.ErrorIs(t, r.Process(context.Background(), "a", invalid), types.ErrInvalidPayload)
+}
+
+func TestReassembler_ForgedChunkCount(t *testing.T) {
+	var delivered int
+
+	r := chunk.NewReassembler(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		delivered++
+		return nil
+	}), chunk.ReassembleOptions{MaxGroups: 2})
+
+	forged := func(group string, index, count, size int) types.Message {
+		return types.Message{Payload: []byte("x"), Metadata: map[string]any{
+			chunk.MetadataKeyGroupID: group,
+			chunk.MetadataKeyIndex:   index,
+			chunk.MetadataKeyCount:   count,
+			chunk.MetadataKeySize:    size,
+		}}
+	}
+
+	// Buffered without allocating for the (huge) declared count.
+	require.NoError(t, r.Process(context.Background(), "a", forged("g1", 0, 1<<40, 1<<41)))
+	require.NoError(t, r.Process(context.Background(), "a", forged("g1", 1<<39, 1<<40, 1<<41)))
+
+	// More chunks than bytes.
+	assert.ErrorIs(t, r.Process(context.Background(), "a", forged("g2", 0, 1<<40, 10)), types.ErrInvalidPayload)
+	assert.ErrorIs(t, r.Process(context.Background(), "a", forged("g2", 0, 2, 0)), types.ErrInvalidPayload)
+
+	// Chunks exceeding the declared size.
+	require.NoError(t, r.Process(context.Background(), "a", forged("g3", 0, 2, 2)))
+	big := forged("g3", 1, 2, 2)
+	big.Payload = []byte("xx")
+	assert.ErrorIs(t, r.Process(context.Background(), "a", big), types.ErrInvalidPayload)
+
+	// Group limit, g1 and g4 are incomplete.
+	require.NoError(t, r.Process(context.Background(), "a", forged("g4", 0, 2, 2)))
+	assert.ErrorIs(t, r.Process(context.Background(), "a", forged("g5", 0, 2, 2)), types.ErrBackoff)
+
+	assert.Zero(t, delivered)
+
+	limited := chunk.NewReassembler(r, chunk.ReassembleOptions{MaxPayloadSize: 1 << 20, MaxMemory: 1 << 20})
+	assert.ErrorIs(t, limited.Process(context.Background(), "a", forged("g6", 0, 1<<40, 1<<41)), types.ErrPayloadTooLarge)
+
+	limited = chunk.NewReassembler(r, chunk.ReassembleOptions{MaxMemory: 1 << 20})
+	assert.ErrorIs(t, limited.Process(context.Background(), "a", forged("g6", 0, 1<<19, 1<<19)), types.ErrPayloadTooLarge)
+}
@@ -0,0 +1,307 @@
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// DefaultTimeout is the default time a incomplete group is kept before it is dropped.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxGroups is the default max number of incomplete groups buffered at the same time.
+const DefaultMaxGroups = 1024
+
+// chunkOverhead is the approximate memory, in bytes, used to track a buffered chunk besides its data.
+const chunkOverhead = 64
+
+// ReassembleOptions configures the `Reassembler`.
+type ReassembleOptions struct {
+	// Timeout is the max time, from the first received chunk, for a group to complete. If zero, `DefaultTimeout`
+	// is used.
+	Timeout time.Duration
+	// MaxPayloadSize is the max size, in bytes, of a reassembled payload. If zero, there is no limit.
+	MaxPayloadSize int
+	// MaxMemory is the max number of bytes buffered for all incomplete groups, including the bookkeeping of each
+	// chunk. If zero, there is no limit.
+	MaxMemory int
+	// MaxGroups is the max number of incomplete groups. If zero, `DefaultMaxGroups` is used.
+	MaxGroups int
+	// OnDropped is an optional callback invoked when a incomplete group is dropped.
+	OnDropped func(groupID string, err error)
+}
+
+type group struct {
+	// chunks are allocated as received, hence a forged chunk count does not allocate memory up front.
+	chunks   map[int][]byte
+	count    int
+	received int
+	buffered int
+	size     int
+	checksum string
+	metadata map[string]any
+	deadline time.Time
+}
+
+// Reassembler is a `Subscriber` that buffers chunks published by `PublishChunked` and forwards the reassembled
+// message to the next `Subscriber` once all chunks of the group are received, in any order.
+//
+// Messages without chunk metadata are forwarded as is. Duplicate chunks (re-deliveries) are ignored.
+//
+// Incomplete groups are dropped when their timeout elapses or to stay within `ReassembleOptions.MaxMemory`.
+// Expired groups are pruned when chunks are received or when `Prune` is called.
+type Reassembler struct {
+	next     types.Subscriber
+	opts     ReassembleOptions
+	mu       sync.Mutex
+	groups   map[string]*group
+	buffered int
+}
+
+// NewReassembler creates a new `Reassembler` that forwards the reassembled messages to _next_.
+func NewReassembler(next types.Subscriber, opts ReassembleOptions) *Reassembler {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.MaxGroups <= 0 {
+		opts.MaxGroups = DefaultMaxGroups
+	}
+
+	return &Reassembler{next: next, opts: opts, groups: map[string]*group{}}
+}
+
+// SubscriberReassemble creates a `SubscriberMiddleware` that wraps the next `Subscriber` in a `Reassembler`.
+func SubscriberReassemble(opts ReassembleOptions) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return NewReassembler(next, opts)
+	}
+}
+
+// Process buffers the chunk and, when the group is complete, verifies the checksum and forwards the reassembled
+// message.
+//
+// Chunks with invalid metadata, or a group that fails the checksum, are rejected with `types.ErrInvalidPayload`.
+// Groups exceeding the size or memory limits are dropped and rejected with `types.ErrPayloadTooLarge`. The first
+// chunk of a new group is rejected with `types.ErrBackoff` when `ReassembleOptions.MaxGroups` are incomplete.
+func (r *Reassembler) Process(ctx context.Context, topic string, payload types.Message) error {
+	id, ok := payload.Metadata[MetadataKeyGroupID].(string)
+	if !ok || id == "" {
+		return r.next.Process(ctx, topic, payload)
+	}
+
+	index, count, size, err := chunkInfo(payload.Metadata)
+	if err != nil {
+		return err
+	}
+
+	msg, complete, err := r.add(id, index, count, size, payload)
+	if err != nil || !complete {
+		return err
+	}
+
+	return r.next.Process(ctx, topic, msg)
+}
+
+// Prune drops all expired groups.
+func (r *Reassembler) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+}
+
+func (r *Reassembler) add(id string, index, count, size int, payload types.Message) (types.Message, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(now)
+
+	if r.opts.MaxPayloadSize > 0 && size > r.opts.MaxPayloadSize {
+		err := fmt.Errorf("%w: chunked payload of %d bytes exceeds %d", types.ErrPayloadTooLarge, size, r.opts.MaxPayloadSize)
+		r.drop(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	// The limits are enforced against the declared size and count before anything is buffered.
+	if r.opts.MaxMemory > 0 && size+count*chunkOverhead > r.opts.MaxMemory {
+		err := fmt.Errorf("%w: chunked payload of %d bytes exceeds the %d bytes buffer", types.ErrPayloadTooLarge, size, r.opts.MaxMemory)
+		r.drop(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	g, ok := r.groups[id]
+	if !ok {
+		if len(r.groups) >= r.opts.MaxGroups {
+			return types.Message{}, false, fmt.Errorf("%w: %d incomplete chunk groups", types.ErrBackoff, len(r.groups))
+		}
+
+		checksum, _ := payload.Metadata[MetadataKeyChecksum].(string)
+
+		g = &group{
+			chunks:   map[int][]byte{},
+			count:    count,
+			size:     size,
+			checksum: checksum,
+			deadline: now.Add(r.opts.Timeout),
+		}
+
+		r.groups[id] = g
+	}
+
+	if count != g.count || size != g.size {
+		err := fmt.Errorf("%w: chunk %d of group %q does not match the group", types.ErrInvalidPayload, index, id)
+		r.drop(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	if _, ok := g.chunks[index]; ok {
+		return types.Message{}, false, nil
+	}
+
+	cost := len(payload.Payload) + chunkOverhead
+
+	if g.received+len(payload.Payload) > g.size {
+		err := fmt.Errorf("%w: chunk group %q exceeds its declared size %d", types.ErrInvalidPayload, id, g.size)
+		r.drop(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	if r.opts.MaxMemory > 0 && r.buffered+cost > r.opts.MaxMemory {
+		err := fmt.Errorf("%w: chunk buffer exceeds %d bytes", types.ErrPayloadTooLarge, r.opts.MaxMemory)
+		r.drop(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	g.chunks[index] = bytes.Clone(payload.Payload)
+	g.received += len(payload.Payload)
+	g.buffered += cost
+	r.buffered += cost
+
+	if index == 0 {
+		g.metadata = payload.Metadata
+	}
+
+	if len(g.chunks) < g.count {
+		return types.Message{}, false, nil
+	}
+
+	delete(r.groups, id)
+	r.buffered -= g.buffered
+
+	data := make([]byte, 0, g.size)
+	for i := range g.count {
+		data = append(data, g.chunks[i]...)
+	}
+
+	sum := sha256.Sum256(data)
+	if len(data) != g.size || hex.EncodeToString(sum[:]) != g.checksum {
+		err := fmt.Errorf("%w: checksum mismatch for chunk group %q", types.ErrInvalidPayload, id)
+		r.dropped(id, err)
+
+		return types.Message{}, false, err
+	}
+
+	meta := maps.Clone(g.metadata)
+	delete(meta, MetadataKeyGroupID)
+	delete(meta, MetadataKeyIndex)
+	delete(meta, MetadataKeyCount)
+	delete(meta, MetadataKeySize)
+	delete(meta, MetadataKeyChecksum)
+
+	payload.Payload = data
+	payload.Metadata = meta
+
+	return payload, true, nil
+}
+
+// prune drops expired groups, the lock must be held.
+func (r *Reassembler) prune(now time.Time) {
+	for id, g := range r.groups {
+		if now.After(g.deadline) {
+			r.drop(id, fmt.Errorf("%w: chunk group %q incomplete after %s", types.ErrMessageExpired, id, r.opts.Timeout))
+		}
+	}
+}
+
+// drop removes the group, the lock must be held.
+func (r *Reassembler) drop(id string, err error) {
+	g, ok := r.groups[id]
+	if !ok {
+		return
+	}
+
+	delete(r.groups, id)
+	r.buffered -= g.buffered
+
+	r.dropped(id, err)
+}
+
+func (r *Reassembler) dropped(id string, err error) {
+	if r.opts.OnDropped != nil {
+		r.opts.OnDropped(id, err)
+	}
+}
+
+func chunkInfo(meta map[string]any) (index, count, size int, err error) {
+	if index, err = metaInt(meta, MetadataKeyIndex); err != nil {
+		return
+	}
+
+	if count, err = metaInt(meta, MetadataKeyCount); err != nil {
+		return
+	}
+
+	if size, err = metaInt(meta, MetadataKeySize); err != nil {
+		return
+	}
+
+	// Each chunk carries at least one byte, except the single chunk of a empty payload.
+	if count <= 0 || index < 0 || index >= count || size < 0 || count > max(size, 1) {
+		err = fmt.Errorf("%w: invalid chunk %d of %d for %d bytes", types.ErrInvalidPayload, index, count, size)
+	}
+
+	return
+}
+
+// metaInt reads a integer metadata value that may have been converted by the transport (e.g. to a string
+// or a JSON number).
+func metaInt(meta map[string]any, key string) (int, error) {
+	switch v := meta[key].(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), nil
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: invalid %q metadata %v", types.ErrInvalidPayload, key, meta[key])
+}
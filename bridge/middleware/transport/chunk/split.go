@@ -0,0 +1,82 @@
+package chunk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyGroupID is the metadata key holding the id shared by all chunks of a payload.
+	MetadataKeyGroupID = "chunk-group-id"
+	// MetadataKeyIndex is the metadata key holding the zero based index of the chunk.
+	MetadataKeyIndex = "chunk-index"
+	// MetadataKeyCount is the metadata key holding the total number of chunks in the group.
+	MetadataKeyCount = "chunk-count"
+	// MetadataKeySize is the metadata key holding the size, in bytes, of the complete payload.
+	MetadataKeySize = "chunk-size"
+	// MetadataKeyChecksum is the metadata key holding the hex encoded SHA-256 of the complete payload.
+	MetadataKeyChecksum = "chunk-checksum"
+)
+
+// SplitOptions configures the `PublishChunked` middleware.
+type SplitOptions struct {
+	// ChunkSize is the max size, in bytes, of each chunk. Payloads not larger than this are published as is.
+	//
+	// NOTE: Leave headroom for the metadata when the transport limit includes it (e.g. MQTT max packet size).
+	ChunkSize int
+}
+
+// PublishChunked creates a `PublisherMiddleware` that splits payloads larger than `SplitOptions.ChunkSize` into
+// ordered chunks, published one by one, each carrying the group id, index, count, size and checksum metadata
+// (see the `MetadataKey*` constants). The remaining metadata is copied onto every chunk.
+//
+// If a chunk fails to publish, the error is returned and no further chunks are published. The partial group is
+// eventually dropped by the `Reassembler`.
+func PublishChunked(opts SplitOptions) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if opts.ChunkSize <= 0 || len(payload.Payload) <= opts.ChunkSize {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			var id [16]byte
+			if _, err := rand.Read(id[:]); err != nil {
+				return err
+			}
+
+			sum := sha256.Sum256(payload.Payload)
+			data := payload.Payload
+			count := (len(data) + opts.ChunkSize - 1) / opts.ChunkSize
+
+			for i := range count {
+				end := min((i+1)*opts.ChunkSize, len(data))
+
+				meta := maps.Clone(payload.Metadata)
+				if meta == nil {
+					meta = map[string]any{}
+				}
+
+				meta[MetadataKeyGroupID] = hex.EncodeToString(id[:])
+				meta[MetadataKeyIndex] = i
+				meta[MetadataKeyCount] = count
+				meta[MetadataKeySize] = len(data)
+				meta[MetadataKeyChecksum] = hex.EncodeToString(sum[:])
+
+				chunk := payload
+				chunk.Payload = data[i*opts.ChunkSize : end]
+				chunk.Metadata = meta
+
+				if err := next.Publish(ctx, topic, chunk); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package claimcheck
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/blob"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyClaimCheck is the metadata key holding the `blob.Store` key of the offloaded payload.
+	MetadataKeyClaimCheck = "claim-check"
+	// MetadataKeyClaimCheckSize is the metadata key holding the size, in bytes, of the offloaded payload.
+	MetadataKeyClaimCheckSize = "claim-check-size"
+)
+
+// MaxPayloadSize returns the max payload size, in bytes, of the _transport_ or zero if unknown.
+//
+// For Azure Service Bus, the standard tier limit is returned.
+func MaxPayloadSize(transport types.TransportType) int {
+	switch transport {
+	case types.TransportTypeSQS, types.TransportTypeAzureServiceBus:
+		return 256 * 1024
+	case types.TransportTypeMQTT:
+		return 268435455
+	default:
+		return 0
+	}
+}
+
+// Options configures the claim-check middlewares.
+type Options struct {
+	// Store is where the payloads are offloaded to.
+	Store blob.Store
+	// Threshold is the payload size, in bytes, above which the payload is offloaded. If zero, the
+	// `MaxPayloadSize` of _TransportType_ is used.
+	//
+	// NOTE: Leave headroom for the metadata since most transports include it in their size limit.
+	Threshold int
+	// TransportType is used to resolve the _Threshold_ when not set.
+	TransportType types.TransportType
+	// KeyFunc optionally creates the blob key for a payload. The default is a random key prefixed by `Prefix`.
+	// The created keys must start with `Prefix`.
+	KeyFunc func(topic string, payload types.Message) string
+	// Prefix is prepended to the generated blob keys, e.g. `claim-check/`. Since the keys are received in
+	// metadata, the `Subscriber` only rehydrates, and deletes, blobs whose key starts with _Prefix_.
+	//
+	// Required when _DeleteAfterProcess_ is set.
+	Prefix string
+	// DeleteAfterProcess removes the blob once the `Subscriber` has successfully processed the message.
+	//
+	// Only enable it when the message has a single consumer.
+	DeleteAfterProcess bool
+	// MaxSize is the max size, in bytes, of a rehydrated payload. If zero, `blob.DefaultMaxSize` is used.
+	MaxSize int64
+}
+
+// PublishClaimCheck creates a `PublisherMiddleware` that offloads payloads larger than the threshold to the
+// `Options.Store` and publishes a empty payload with a reference (`MetadataKeyClaimCheck`) instead.
+//
+// If the threshold cannot be resolved, the messages are passed through untouched.
+func PublishClaimCheck(opts Options) types.PublisherMiddleware {
+	threshold := opts.threshold()
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if threshold <= 0 || len(payload.Payload) <= threshold {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			key, err := opts.key(topic, payload)
+			if err != nil {
+				return err
+			}
+
+			if err := opts.Store.Put(ctx, key, payload.Payload); err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			meta[MetadataKeyClaimCheck] = key
+			meta[MetadataKeyClaimCheckSize] = len(payload.Payload)
+
+			payload.Payload = nil
+			payload.Metadata = meta
+
+			if err := next.Publish(ctx, topic, payload); err != nil {
+				_ = opts.Store.Delete(context.WithoutCancel(ctx), key)
+				return err
+			}
+
+			return nil
+		})
+	}
+}
+
+// SubscriberClaimCheck creates a `SubscriberMiddleware` that rehydrates payloads offloaded by `PublishClaimCheck`
+// and removes the claim-check metadata before passing the message on.
+//
+// A missing blob is returned as a `types.ErrNotFound` wrapped error, invalid references, or references outside
+// of `Options.Prefix`, as `types.ErrInvalidPayload` and payloads larger than `Options.MaxSize` as
+// `types.ErrPayloadTooLarge`. Other `blob.Store` errors are returned as is.
+//
+// An error is returned if `Options.DeleteAfterProcess` is set without a `Options.Prefix`.
+func SubscriberClaimCheck(opts Options) (types.SubscriberMiddleware, error) {
+	if opts.Store == nil {
+		return nil, errors.New("claim-check: missing store")
+	}
+
+	if opts.DeleteAfterProcess && opts.Prefix == "" {
+		return nil, errors.New("claim-check: delete after process requires a prefix")
+	}
+
+	limit := opts.MaxSize
+	if limit <= 0 {
+		limit = blob.DefaultMaxSize
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			ref, ok := payload.Metadata[MetadataKeyClaimCheck]
+			if !ok {
+				return next.Process(ctx, topic, payload)
+			}
+
+			key, ok := ref.(string)
+			if !ok {
+				return fmt.Errorf("%w: claim-check reference must be a string, got %T", types.ErrInvalidPayload, ref)
+			}
+
+			if err := blob.ValidateKey(key); err != nil {
+				return err
+			}
+
+			if !strings.HasPrefix(key, opts.Prefix) {
+				return fmt.Errorf("%w: claim-check reference %q outside of %q", types.ErrInvalidPayload, key, opts.Prefix)
+			}
+
+			size := payload.GetMetadataInt(MetadataKeyClaimCheckSize, -1)
+			if int64(size) > limit {
+				return fmt.Errorf("%w: claim-check payload exceeds %d bytes", types.ErrPayloadTooLarge, limit)
+			}
+
+			data, err := opts.Store.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+
+			if int64(len(data)) > limit {
+				return fmt.Errorf("%w: claim-check payload exceeds %d bytes", types.ErrPayloadTooLarge, limit)
+			}
+
+			if size >= 0 && len(data) != size {
+				return fmt.Errorf("%w: claim-check payload is %d bytes, expected %d", types.ErrInvalidPayload, len(data), size)
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			delete(meta, MetadataKeyClaimCheck)
+			delete(meta, MetadataKeyClaimCheckSize)
+
+			payload.Payload = data
+			payload.Metadata = meta
+
+			if err := next.Process(ctx, topic, payload); err != nil {
+				return err
+			}
+
+			if opts.DeleteAfterProcess {
+				// A failed delete must not make the `Connection` re-deliver a processed message.
+				_ = opts.Store.Delete(ctx, key)
+			}
+
+			return nil
+		})
+	}, nil
+}
+
+func (o Options) threshold() int {
+	if o.Threshold > 0 {
+		return o.Threshold
+	}
+
+	return MaxPayloadSize(o.TransportType)
+}
+
+func (o Options) key(topic string, payload types.Message) (string, error) {
+	if o.KeyFunc != nil {
+		key := o.KeyFunc(topic, payload)
+		if !strings.HasPrefix(key, o.Prefix) {
+			return "", fmt.Errorf("claim-check: key %q does not start with prefix %q", key, o.Prefix)
+		}
+
+		return key, nil
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+
+	return o.Prefix + hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,113 @@
+package claimcheck_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/blob"
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/claimcheck"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimCheck_RoundTrip(t *testing.T) {
+	store := blob.NewMemoryStore()
+	opts := claimcheck.Options{Store: store, TransportType: types.TransportTypeSQS, Prefix: "cc/", DeleteAfterProcess: true}
+
+	var (
+		wire types.Message
+		got  types.Message
+	)
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = payload
+		return nil
+	}), subscriberClaimCheck(t, opts))
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return sub.Process(ctx, topic, payload)
+	}), claimcheck.PublishClaimCheck(opts))
+
+	manifest := bytes.Repeat([]byte("x"), 300*1024)
+
+	require.NoError(t, pub.Publish(context.Background(), "firmware", types.Message{Payload: manifest}))
+
+	assert.Empty(t, wire.Payload)
+	assert.Equal(t, len(manifest), wire.Metadata[claimcheck.MetadataKeyClaimCheckSize])
+	assert.Contains(t, wire.Metadata[claimcheck.MetadataKeyClaimCheck], "cc/")
+
+	assert.Equal(t, manifest, got.Payload)
+	assert.NotContains(t, got.Metadata, claimcheck.MetadataKeyClaimCheck)
+	assert.Empty(t, store.Keys(), "blob must be deleted after processing")
+
+	require.NoError(t, pub.Publish(context.Background(), "firmware", types.Message{Payload: []byte("small")}))
+	assert.Equal(t, "small", string(wire.Payload))
+	assert.NotContains(t, wire.Metadata, claimcheck.MetadataKeyClaimCheck)
+}
+
+func subscriberClaimCheck(t *testing.T, opts claimcheck.Options) types.SubscriberMiddleware {
+	t.Helper()
+
+	mw, err := claimcheck.SubscriberClaimCheck(opts)
+	require.NoError(t, err)
+
+	return mw
+}
+
+func TestSubscriberClaimCheck_ValidatesOptions(t *testing.T) {
+	_, err := claimcheck.SubscriberClaimCheck(claimcheck.Options{})
+	assert.Error(t, err)
+
+	_, err = claimcheck.SubscriberClaimCheck(claimcheck.Options{Store: blob.NewMemoryStore(), DeleteAfterProcess: true})
+	assert.Error(t, err)
+}
+
+func TestSubscriberClaimCheck_InvalidReference(t *testing.T) {
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), subscriberClaimCheck(t, claimcheck.Options{Store: blob.NewMemoryStore()}))
+
+	msg := func(ref any) types.Message {
+		return types.Message{Metadata: map[string]any{claimcheck.MetadataKeyClaimCheck: ref}}
+	}
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("../../etc/passwd")), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg(42)), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("missing")), types.ErrNotFound)
+}
+
+func TestSubscriberClaimCheck_RejectsKeysOutsidePrefixAndLargePayloads(t *testing.T) {
+	store := blob.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), "other/a", []byte("secret")))
+	require.NoError(t, store.Put(context.Background(), "cc/a", []byte("payload")))
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), subscriberClaimCheck(t, claimcheck.Options{Store: store, Prefix: "cc/", DeleteAfterProcess: true, MaxSize: 4}))
+
+	msg := func(key string, size any) types.Message {
+		meta := map[string]any{claimcheck.MetadataKeyClaimCheck: key}
+		if size != nil {
+			meta[claimcheck.MetadataKeyClaimCheckSize] = size
+		}
+
+		return types.Message{Metadata: meta}
+	}
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("other/a", nil)), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("cc/a", "7")), types.ErrPayloadTooLarge)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("cc/a", nil)), types.ErrPayloadTooLarge)
+	assert.ElementsMatch(t, []string{"other/a", "cc/a"}, store.Keys(), "rejected blobs must not be deleted")
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), claimcheck.PublishClaimCheck(claimcheck.Options{
+		Store: store, Threshold: 1, Prefix: "cc/",
+		KeyFunc: func(topic string, payload types.Message) string { return "other/b" },
+	}))
+
+	assert.Error(t, pub.Publish(context.Background(), "a", types.Message{Payload: []byte("payload")}))
+}
@@ -0,0 +1,59 @@
+package cloudevents
+
+import (
+	"context"
+
+	"github.com/mariotoffia/gobridge/bridge/cloudevents"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the required attributes middlewares.
+type Options struct {
+	// Binding is used to decode binary mode events. If zero, `cloudevents.BindingDefault` is used.
+	Binding cloudevents.Binding
+	// Required are the attributes (or extensions), in addition to _id_, _source_ and _type_, that must be
+	// present, e.g. `subject` or `traceparent`.
+	Required []string
+}
+
+// PublishRequired creates a `PublisherMiddleware` that rejects messages that are not valid _CloudEvents_
+// (binary or structured mode) or lack any of the required attributes with `types.ErrInvalidPayload`.
+func PublishRequired(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := opts.validate(payload); err != nil {
+				return err
+			}
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberRequired creates a `SubscriberMiddleware` that rejects received messages in the same manner
+// as `PublishRequired`.
+func SubscriberRequired(opts Options) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := opts.validate(payload); err != nil {
+				return err
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+func (o Options) validate(payload types.Message) error {
+	binding := o.Binding
+	if binding == (cloudevents.Binding{}) {
+		binding = cloudevents.BindingDefault
+	}
+
+	event, err := binding.FromMessage(payload)
+	if err != nil {
+		return err
+	}
+
+	return event.Validate(o.Required...)
+}
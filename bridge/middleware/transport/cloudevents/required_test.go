@@ -0,0 +1,29 @@
+package cloudevents_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/cloudevents"
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/cloudevents"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishRequired(t *testing.T) {
+	published := 0
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		published++
+		return nil
+	}), mw.PublishRequired(mw.Options{Binding: cloudevents.BindingMQTT5, Required: []string{"subject"}}))
+
+	event := cloudevents.Event{ID: "1", Source: "/devices/1", Type: "com.example.reading"}
+
+	assert.ErrorIs(t, pub.Publish(context.Background(), "devices/1", cloudevents.BindingMQTT5.ToBinary(event, "devices/1")), types.ErrInvalidPayload)
+	assert.ErrorIs(t, pub.Publish(context.Background(), "devices/1", types.Message{Payload: []byte("{}")}), types.ErrInvalidPayload)
+
+	event.Subject = "temp"
+	assert.NoError(t, pub.Publish(context.Background(), "devices/1", cloudevents.BindingMQTT5.ToBinary(event, "devices/1")))
+	assert.Equal(t, 1, published)
+}
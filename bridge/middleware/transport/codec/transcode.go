@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"context"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the transcoding middlewares.
+type Options struct {
+	// Registry is used to look up the codecs. If `nil`, `codec.DefaultRegistry` is used.
+	Registry *codec.Registry
+	// Target is the content type the payloads are transcoded into, e.g. `application/json`.
+	Target string
+	// Default is the content type assumed for messages without the `codec.MetadataKeyContentType` metadata.
+	// If empty, such messages are passed on untouched.
+	Default string
+}
+
+// PublishTranscode creates a `PublisherMiddleware` that transcodes the payload from its content type
+// (see `codec.ContentType`) into `Options.Target` and updates the content type metadata accordingly.
+//
+// Unknown content types are rejected with `types.ErrProtocolMismatch` and payloads that fail to decode
+// with `types.ErrInvalidPayload`.
+func PublishTranscode(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			payload, err := opts.transcode(payload)
+			if err != nil {
+				return err
+			}
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberTranscode creates a `SubscriberMiddleware` that transcodes the received payload in the same manner
+// as `PublishTranscode`, e.g. CBOR from devices into JSON for the backends.
+func SubscriberTranscode(opts Options) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			payload, err := opts.transcode(payload)
+			if err != nil {
+				return err
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+func (o Options) transcode(payload types.Message) (types.Message, error) {
+	registry := o.Registry
+	if registry == nil {
+		registry = codec.DefaultRegistry
+	}
+
+	source := codec.ContentType(payload, o.Default)
+	if source == "" {
+		return payload, nil
+	}
+
+	from, err := registry.Get(source)
+	if err != nil {
+		return payload, err
+	}
+
+	to, err := registry.Get(o.Target)
+	if err != nil {
+		return payload, err
+	}
+
+	data, err := codec.Transcode(payload.Payload, from, to)
+	if err != nil {
+		return payload, err
+	}
+
+	meta := maps.Clone(payload.Metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+
+	meta[codec.MetadataKeyContentType] = to.ContentType()
+
+	payload.Payload = data
+	payload.Metadata = meta
+
+	return payload, nil
+}
@@ -0,0 +1,34 @@
+package codec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberTranscode_CBORToJSON(t *testing.T) {
+	var got types.Message
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = payload
+		return nil
+	}), mw.SubscriberTranscode(mw.Options{Target: codec.ContentTypeJSON, Default: codec.ContentTypeCBOR}))
+
+	data, err := codec.CBOR{}.Marshal(map[string]any{"temp": 21.5})
+	require.NoError(t, err)
+
+	require.NoError(t, sub.Process(context.Background(), "devices/1", types.Message{Payload: data}))
+	assert.JSONEq(t, `{"temp":21.5}`, string(got.Payload))
+	assert.Equal(t, codec.ContentTypeJSON, got.Metadata[codec.MetadataKeyContentType])
+
+	bad := types.Message{Payload: []byte{0xff}, Metadata: map[string]any{codec.MetadataKeyContentType: codec.ContentTypeCBOR}}
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/1", bad), types.ErrInvalidPayload)
+
+	unknown := types.Message{Metadata: map[string]any{codec.MetadataKeyContentType: "text/plain"}}
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/1", unknown), types.ErrProtocolMismatch)
+}
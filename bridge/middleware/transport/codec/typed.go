@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"context"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// EncodeOptions configures the `PublishEncode` middleware.
+type EncodeOptions struct {
+	// Codec encodes the values. If `nil`, the `codec.JSON` codec is used.
+	Codec codec.Codec
+}
+
+// DecodeOptions configures the `SubscriberDecode` middleware.
+type DecodeOptions struct {
+	// Registry is used to look up the codec. If `nil`, `codec.DefaultRegistry` is used.
+	Registry *codec.Registry
+	// Default is the content type assumed for messages without the `codec.MetadataKeyContentType` metadata.
+	// If empty, `codec.ContentTypeJSON` is used.
+	Default string
+}
+
+type (
+	encodeKey  struct{}
+	decodedKey struct{}
+)
+
+// WithValue returns a copy of _ctx_ carrying the typed value _v_ that `PublishEncode` encodes into the
+// `Message.Payload`.
+func WithValue(ctx context.Context, v any) context.Context {
+	return context.WithValue(ctx, encodeKey{}, v)
+}
+
+// Value returns the value decoded by `SubscriberDecode` from _ctx_.
+func Value[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(decodedKey{}).(T)
+	return v, ok
+}
+
+// PublishEncode creates a `PublisherMiddleware` that encodes the typed value attached to _ctx_ (see `WithValue`)
+// into the `Message.Payload` and sets the `codec.MetadataKeyContentType` metadata. Messages published without
+// a value are passed on untouched.
+//
+// Encode failures are returned as `types.ErrInvalidPayload` wrapped errors.
+func PublishEncode(opts EncodeOptions) types.PublisherMiddleware {
+	c := opts.Codec
+	if c == nil {
+		c = codec.JSON{}
+	}
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			v, ok := ctx.Value(encodeKey{}).(any)
+			if !ok {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			encoded, err := codec.Encode(c, topic, v)
+			if err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			maps.Copy(meta, encoded.Metadata)
+
+			payload.Payload = encoded.Payload
+			payload.Metadata = meta
+
+			// The value is encoded, hence not to be encoded again further down the chain.
+			return next.Publish(context.WithValue(ctx, encodeKey{}, nil), topic, payload)
+		})
+	}
+}
+
+// SubscriberDecode creates a `SubscriberMiddleware` that decodes the received payload into a _T_, using the codec
+// for its content type, and makes it available to the next `Subscriber` through `Value`.
+//
+// Payloads that fail to decode are returned as `types.ErrInvalidPayload` wrapped errors and unknown content types
+// as `types.ErrProtocolMismatch`, the next `Subscriber` is not invoked.
+func SubscriberDecode[T any](opts DecodeOptions) types.SubscriberMiddleware {
+	if opts.Registry == nil {
+		opts.Registry = codec.DefaultRegistry
+	}
+
+	if opts.Default == "" {
+		opts.Default = codec.ContentTypeJSON
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			var v T
+			if err := codec.Decode(opts.Registry, payload, opts.Default, &v); err != nil {
+				return err
+			}
+
+			return next.Process(context.WithValue(ctx, decodedKey{}, v), topic, payload)
+		})
+	}
+}
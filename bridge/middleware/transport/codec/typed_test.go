@@ -0,0 +1,52 @@
+package codec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/codec"
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/codec"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type command struct {
+	Action string `json:"action"`
+	Level  int    `json:"level"`
+}
+
+func TestTypedMiddlewares_EncodeAndDecode(t *testing.T) {
+	var wire types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return nil
+	}), mw.PublishEncode(mw.EncodeOptions{Codec: codec.CBOR{}}))
+
+	ctx := mw.WithValue(context.Background(), command{Action: "dim", Level: 3})
+	require.NoError(t, pub.Publish(ctx, "devices/1/cmd", types.Message{Metadata: map[string]any{"k": "v"}}))
+
+	assert.Equal(t, codec.ContentTypeCBOR, wire.Metadata[codec.MetadataKeyContentType])
+	assert.Equal(t, "v", wire.Metadata["k"])
+
+	var got command
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		var ok bool
+		got, ok = mw.Value[command](ctx)
+		require.True(t, ok)
+
+		return nil
+	}), mw.SubscriberDecode[command](mw.DecodeOptions{}))
+
+	require.NoError(t, sub.Process(context.Background(), "devices/1/cmd", wire))
+	assert.Equal(t, command{Action: "dim", Level: 3}, got)
+
+	bad := types.Message{Payload: []byte{0xff}, Metadata: map[string]any{codec.MetadataKeyContentType: codec.ContentTypeCBOR}}
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/1/cmd", bad), types.ErrInvalidPayload)
+
+	// Without a value the message is published as is.
+	require.NoError(t, pub.Publish(context.Background(), "devices/1/cmd", types.Message{Payload: []byte("raw")}))
+	assert.Equal(t, []byte("raw"), wire.Payload)
+}
@@ -0,0 +1,392 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// MetadataKeyContentEncoding is the metadata key holding the encoding (compression) applied to the `Message.Payload`.
+const MetadataKeyContentEncoding = string(types.MessageMetadataKeysContentEncoding)
+
+const (
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingZstd     = "zstd"
+	EncodingSnappy   = "snappy"
+	EncodingIdentity = "identity"
+)
+
+// DefaultMaxExpandedSize is the default max size, in bytes, a payload may expand to when decompressed.
+const DefaultMaxExpandedSize = 16 << 20
+
+// Compressor compresses and decompresses payloads of a single content encoding.
+//
+// Additional encodings are plugged in by implementing this interface and passing it in `Options`.
+type Compressor interface {
+	// Encoding returns the content encoding name, e.g. `gzip`.
+	Encoding() string
+	// NewWriter returns a writer that compresses into _w_.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader that decompresses _r_. The decompressed data must not exceed _limit_ bytes:
+	// `Decompress` stops reading beyond it, while decoders allocating up front (e.g. a window) must bound the
+	// allocation by _limit_ and fail with `types.ErrPayloadTooLarge` if they cannot.
+	NewReader(r io.Reader, limit int64) (io.ReadCloser, error)
+}
+
+// Gzip is the `compress/gzip` `Compressor`. A zero _Level_ uses `gzip.DefaultCompression`.
+type Gzip struct {
+	Level int
+}
+
+// Encoding returns `EncodingGzip`.
+func (Gzip) Encoding() string { return EncodingGzip }
+
+// NewWriter returns a gzip writer.
+func (g Gzip) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level(g.Level, gzip.DefaultCompression))
+}
+
+// NewReader returns a gzip reader.
+func (Gzip) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) { return gzip.NewReader(r) }
+
+// Deflate is the `compress/flate` `Compressor`. A zero _Level_ uses `flate.DefaultCompression`.
+type Deflate struct {
+	Level int
+}
+
+// Encoding returns `EncodingDeflate`.
+func (Deflate) Encoding() string { return EncodingDeflate }
+
+// NewWriter returns a flate writer.
+func (d Deflate) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, level(d.Level, flate.DefaultCompression))
+}
+
+// NewReader returns a flate reader.
+func (Deflate) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) { return flate.NewReader(r), nil }
+
+// Zstd is the _Zstandard_ `Compressor`. A zero _Level_ uses `zstd.SpeedDefault`.
+//
+// Payloads are encoded as single frames declaring their content size. The encoders, per level, and the
+// decoders, per limit, are shared between all messages.
+type Zstd struct {
+	Level zstd.EncoderLevel
+}
+
+var (
+	zstdEncoders sync.Map // zstd.EncoderLevel -> *zstd.Encoder
+	zstdDecoders sync.Map // int64 -> *zstd.Decoder
+)
+
+// Encoding returns `EncodingZstd`.
+func (Zstd) Encoding() string { return EncodingZstd }
+
+// NewWriter returns a writer that buffers the payload and encodes it into _w_ when closed.
+func (z Zstd) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	l := zstd.EncoderLevel(level(int(z.Level), int(zstd.SpeedDefault)))
+
+	if enc, ok := zstdEncoders.Load(l); ok {
+		return &zstdWriter{w: w, enc: enc.(*zstd.Encoder)}, nil
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(l))
+	if err != nil {
+		return nil, err
+	}
+
+	shared, _ := zstdEncoders.LoadOrStore(l, enc)
+
+	return &zstdWriter{w: w, enc: shared.(*zstd.Encoder)}, nil
+}
+
+// NewReader returns a reader of the decoded _r_. The decoder memory and window are bound by _limit_, frames
+// requiring more are rejected with `types.ErrPayloadTooLarge`.
+func (Zstd) NewReader(r io.Reader, limit int64) (io.ReadCloser, error) {
+	dec, err := zstdDecoder(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := dec.DecodeAll(data, nil)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return nil, fmt.Errorf("%w: decompressed payload exceeds %d bytes", types.ErrPayloadTooLarge, limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(out)), nil
+}
+
+// zstdDecoder returns the shared decoder bound by _limit_.
+func zstdDecoder(limit int64) (*zstd.Decoder, error) {
+	if dec, ok := zstdDecoders.Load(limit); ok {
+		return dec.(*zstd.Decoder), nil
+	}
+
+	dec, err := zstd.NewReader(nil,
+		zstd.WithDecoderMaxMemory(uint64(max(limit, 1))),
+		zstd.WithDecoderMaxWindow(uint64(max(limit, zstd.MinWindowSize))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, loaded := zstdDecoders.LoadOrStore(limit, dec)
+	if loaded {
+		dec.Close()
+	}
+
+	return shared.(*zstd.Decoder), nil
+}
+
+// zstdWriter buffers the written data and encodes it as a single frame on `Close`.
+type zstdWriter struct {
+	w   io.Writer
+	enc *zstd.Encoder
+	buf []byte
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) {
+	z.buf = append(z.buf, p...)
+	return len(p), nil
+}
+
+func (z *zstdWriter) Close() error {
+	_, err := z.w.Write(z.enc.EncodeAll(z.buf, nil))
+	return err
+}
+
+// Snappy is the framed _Snappy_ `Compressor`.
+type Snappy struct{}
+
+// Encoding returns `EncodingSnappy`.
+func (Snappy) Encoding() string { return EncodingSnappy }
+
+// NewWriter returns a buffered snappy framing writer.
+func (Snappy) NewWriter(w io.Writer) (io.WriteCloser, error) { return snappy.NewBufferedWriter(w), nil }
+
+// NewReader returns a snappy framing reader.
+func (Snappy) NewReader(r io.Reader, _ int64) (io.ReadCloser, error) {
+	return io.NopCloser(snappy.NewReader(r)), nil
+}
+
+func level(l, def int) int {
+	if l == 0 {
+		return def
+	}
+
+	return l
+}
+
+// Options configures the compression middlewares.
+type Options struct {
+	// Compressor is used when publishing. If `nil`, `Gzip` is used.
+	Compressor Compressor
+	// Threshold is the minimum payload size, in bytes, for a payload to be compressed.
+	Threshold int
+	// Compressors are additional encodings that may be negotiated (see _AcceptEncoding_) and are accepted when
+	// decompressing. `Gzip`, `Deflate`, `Zstd`, `Snappy` and the _Compressor_ are always available.
+	Compressors []Compressor
+	// AcceptEncoding optionally negotiates the encoding per _topic_ when publishing. It returns the encodings
+	// accepted by the consumers of the _topic_, in order of preference, and the first available one is used.
+	// When none is available, or `EncodingIdentity` comes first, the payload is published uncompressed.
+	// If `nil`, the _Compressor_ is always used.
+	AcceptEncoding func(topic string) []string
+	// MaxExpandedSize is the max size, in bytes, a payload may expand to when decompressed. If zero,
+	// `DefaultMaxExpandedSize` is used.
+	MaxExpandedSize int64
+}
+
+// PublishCompress creates a `PublisherMiddleware` that compresses payloads of at least `Options.Threshold` bytes
+// and sets the `MetadataKeyContentEncoding` metadata. The encoding is negotiated per topic when
+// `Options.AcceptEncoding` is set, otherwise `Options.Compressor` is used.
+//
+// Payloads that already have a content encoding, or do not shrink when compressed, are published as is.
+func PublishCompress(opts Options) types.PublisherMiddleware {
+	compressors := opts.compressors()
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if len(payload.Payload) < opts.Threshold || encoding(payload) != "" {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			c := opts.negotiate(compressors, topic)
+			if c == nil {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			data, err := Compress(c, payload.Payload)
+			if err != nil {
+				return err
+			}
+
+			if len(data) >= len(payload.Payload) {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			meta[MetadataKeyContentEncoding] = c.Encoding()
+
+			payload.Payload = data
+			payload.Metadata = meta
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberDecompress creates a `SubscriberMiddleware` that decompresses payloads based on the
+// `MetadataKeyContentEncoding` metadata and removes it before passing the message on.
+//
+// Unsupported encodings are rejected with `types.ErrProtocolMismatch`, payloads expanding beyond
+// `Options.MaxExpandedSize` with `types.ErrPayloadTooLarge` and corrupt payloads with `types.ErrInvalidPayload`.
+func SubscriberDecompress(opts Options) types.SubscriberMiddleware {
+	compressors := opts.compressors()
+
+	limit := opts.MaxExpandedSize
+	if limit <= 0 {
+		limit = DefaultMaxExpandedSize
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			enc := encoding(payload)
+			if enc == "" || enc == EncodingIdentity {
+				return next.Process(ctx, topic, payload)
+			}
+
+			c, ok := compressors[enc]
+			if !ok {
+				return fmt.Errorf("%w: unsupported content encoding %q", types.ErrProtocolMismatch, enc)
+			}
+
+			data, err := Decompress(c, payload.Payload, limit)
+			if err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			delete(meta, MetadataKeyContentEncoding)
+
+			payload.Payload = data
+			payload.Metadata = meta
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+// Compress compresses _data_ using _c_.
+func Compress(c Compressor, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses _data_ using _c_. If the decompressed data exceeds _limit_ bytes, a
+// `types.ErrPayloadTooLarge` wrapped error is returned without reading further.
+func Decompress(c Compressor, data []byte, limit int64) ([]byte, error) {
+	r, err := c.NewReader(bytes.NewReader(data), limit)
+	if errors.Is(err, types.ErrPayloadTooLarge) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	if int64(len(out)) > limit {
+		return nil, fmt.Errorf("%w: decompressed payload exceeds %d bytes", types.ErrPayloadTooLarge, limit)
+	}
+
+	return out, nil
+}
+
+func (o Options) compressor() Compressor {
+	if o.Compressor == nil {
+		return Gzip{}
+	}
+
+	return o.Compressor
+}
+
+// compressors returns the available compressors by encoding.
+func (o Options) compressors() map[string]Compressor {
+	compressors := map[string]Compressor{}
+
+	for _, c := range append([]Compressor{Gzip{}, Deflate{}, Zstd{}, Snappy{}}, append(o.Compressors, o.compressor())...) {
+		compressors[strings.ToLower(c.Encoding())] = c
+	}
+
+	return compressors
+}
+
+// negotiate returns the `Compressor` to publish on _topic_ with or `nil` if the payload is not to be compressed.
+func (o Options) negotiate(compressors map[string]Compressor, topic string) Compressor {
+	if o.AcceptEncoding == nil {
+		return o.compressor()
+	}
+
+	for _, enc := range o.AcceptEncoding(topic) {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc == EncodingIdentity {
+			return nil
+		}
+
+		if c, ok := compressors[enc]; ok {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func encoding(payload types.Message) string {
+	enc, _ := payload.Metadata[MetadataKeyContentEncoding].(string)
+	return strings.ToLower(strings.TrimSpace(enc))
+}
@@ -0,0 +1,139 @@
+package compress_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/compress"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress_RoundTrip(t *testing.T) {
+	var (
+		sent types.Message
+		got  types.Message
+	)
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = payload
+		return nil
+	}), compress.SubscriberDecompress(compress.Options{}))
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		sent = payload
+		return sub.Process(ctx, topic, payload)
+	}), compress.PublishCompress(compress.Options{Threshold: 64}))
+
+	small := types.Message{Payload: []byte(`{"temp":21.5}`)}
+	require.NoError(t, pub.Publish(context.Background(), "devices/1", small))
+	assert.Nil(t, sent.Metadata[compress.MetadataKeyContentEncoding])
+
+	large := types.Message{Payload: bytes.Repeat([]byte(`{"temp":21.5}`), 100), Metadata: map[string]any{"site": "a"}}
+	require.NoError(t, pub.Publish(context.Background(), "devices/1", large))
+
+	assert.Equal(t, compress.EncodingGzip, sent.Metadata[compress.MetadataKeyContentEncoding])
+	assert.Less(t, len(sent.Payload), len(large.Payload))
+	assert.Equal(t, large.Payload, got.Payload)
+	assert.Equal(t, map[string]any{"site": "a"}, got.Metadata)
+	assert.Equal(t, map[string]any{"site": "a"}, large.Metadata, "original metadata must not be mutated")
+}
+
+func TestSubscriberDecompress_Rejects(t *testing.T) {
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), compress.SubscriberDecompress(compress.Options{MaxExpandedSize: 1024}))
+
+	bomb, err := compress.Compress(compress.Deflate{}, make([]byte, 1<<20))
+	require.NoError(t, err)
+
+	msg := func(enc string, data []byte) types.Message {
+		return types.Message{Payload: data, Metadata: map[string]any{compress.MetadataKeyContentEncoding: enc}}
+	}
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("deflate", bomb)), types.ErrPayloadTooLarge)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("gzip", []byte("garbage"))), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", msg("br", nil)), types.ErrProtocolMismatch)
+}
+
+func TestCompress_ZstdAndSnappy(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"temp":21.5}`), 100)
+
+	for _, c := range []compress.Compressor{compress.Zstd{}, compress.Snappy{}} {
+		data, err := compress.Compress(c, payload)
+		require.NoError(t, err, c.Encoding())
+		assert.Less(t, len(data), len(payload), c.Encoding())
+
+		out, err := compress.Decompress(c, data, int64(len(payload)))
+		require.NoError(t, err, c.Encoding())
+		assert.Equal(t, payload, out, c.Encoding())
+
+		// Bomb guard: 1 MiB of zeros must not be expanded beyond the limit.
+		bomb, err := compress.Compress(c, make([]byte, 1<<20))
+		require.NoError(t, err, c.Encoding())
+
+		_, err = compress.Decompress(c, bomb, 1024)
+		assert.ErrorIs(t, err, types.ErrPayloadTooLarge, c.Encoding())
+
+		_, err = compress.Decompress(c, []byte("garbage"), 1024)
+		assert.ErrorIs(t, err, types.ErrInvalidPayload, c.Encoding())
+	}
+}
+
+func TestPublishCompress_NegotiatesAcceptedEncoding(t *testing.T) {
+	var sent types.Message
+
+	accepted := map[string][]string{
+		"devices/1":  {"br", "zstd", "gzip"},
+		"devices/2":  {"identity", "gzip"},
+		"legacy/1":   {"br"},
+		"backends/1": {"SNAPPY"},
+	}
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		sent = payload
+		return nil
+	}), compress.PublishCompress(compress.Options{AcceptEncoding: func(topic string) []string { return accepted[topic] }}))
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		sent = payload
+		return nil
+	}), compress.SubscriberDecompress(compress.Options{}))
+
+	payload := bytes.Repeat([]byte(`{"temp":21.5}`), 100)
+
+	for topic, want := range map[string]any{
+		"devices/1":  compress.EncodingZstd,
+		"devices/2":  nil,
+		"legacy/1":   nil,
+		"backends/1": compress.EncodingSnappy,
+	} {
+		require.NoError(t, pub.Publish(context.Background(), topic, types.Message{Payload: payload}))
+		assert.Equal(t, want, sent.Metadata[compress.MetadataKeyContentEncoding], topic)
+
+		require.NoError(t, sub.Process(context.Background(), topic, sent))
+		assert.Equal(t, payload, sent.Payload, topic)
+	}
+}
+
+func TestDecompress_ZstdBoundsWindow(t *testing.T) {
+	// A streamed frame declaring a 256 MiB window must be rejected without allocating it.
+	var buf bytes.Buffer
+
+	w, err := zstd.NewWriter(&buf, zstd.WithWindowSize(256<<20))
+	require.NoError(t, err)
+
+	_, err = w.Write(make([]byte, 1<<20))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = compress.Decompress(compress.Zstd{}, buf.Bytes(), 1024)
+	assert.ErrorIs(t, err, types.ErrPayloadTooLarge)
+
+	out, err := compress.Decompress(compress.Zstd{}, buf.Bytes(), 512<<20)
+	require.NoError(t, err)
+	assert.Len(t, out, 1<<20)
+}
@@ -0,0 +1,142 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyError is the metadata key holding the error message that caused the dead-lettering.
+	MetadataKeyError = "dead-letter-error"
+	// MetadataKeyErrorCode is the metadata key holding the `types.BridgeError.HttpCode` (if any).
+	MetadataKeyErrorCode = "dead-letter-error-code"
+	// MetadataKeyRecoverable is the metadata key indicating if the last error was recoverable.
+	MetadataKeyRecoverable = "dead-letter-recoverable"
+	// MetadataKeyAttempts is the metadata key holding the number of processing attempts made.
+	MetadataKeyAttempts = "dead-letter-attempts"
+	// MetadataKeyOriginalTopic is the metadata key holding the topic the message was received on.
+	MetadataKeyOriginalTopic = "dead-letter-original-topic"
+	// MetadataKeyTimestamp is the metadata key holding the time when the message was dead-lettered.
+	MetadataKeyTimestamp = "dead-letter-timestamp"
+)
+
+// Options configures the dead-letter middleware.
+type Options struct {
+	// Publisher is where the failed messages are forwarded to.
+	Publisher types.Publisher
+	// Topic is the dead-letter topic to publish to. If `TopicFunc` is set, it takes precedence.
+	Topic string
+	// TopicFunc optionally resolves the dead-letter topic from the original _topic_.
+	TopicFunc func(topic string) string
+	// MaxAttempts is the maximum number of times a message is processed when the `Subscriber`
+	// returns a recoverable error. When exhausted, the message is dead-lettered.
+	//
+	// If zero or one, no retries are done and recoverable errors are dead-lettered directly.
+	MaxAttempts int
+	// RetryDelay is the delay between retries of recoverable errors.
+	RetryDelay time.Duration
+	// PassThroughBackoff, when `true`, returns `types.BackoffError` errors (e.g. `types.ErrBackoff`) as is so
+	// the `Connection` may re-deliver the message instead of retrying/dead-lettering it here.
+	PassThroughBackoff bool
+}
+
+// DeadLetter creates a `SubscriberMiddleware` that forwards messages the `Subscriber` fails to process
+// to a dead-letter `Publisher`.
+//
+// Non-recoverable errors are dead-lettered directly while recoverable ones are retried up to
+// `Options.MaxAttempts` before being dead-lettered. The dead-lettered message carries the error details,
+// attempt count and original topic in its `Metadata` (see the `MetadataKey*` constants).
+//
+// When the message was successfully dead-lettered, `nil` is returned so the `Connection` acks it. If the
+// dead-letter publish fails, a `types.BackoffError` wrapping both errors is returned so the message is
+// re-delivered instead of lost.
+//
+// An error is returned if no `Options.Publisher` or dead-letter topic (`Options.Topic` or `Options.TopicFunc`)
+// is configured.
+func DeadLetter(opts Options) (types.SubscriberMiddleware, error) {
+	if opts.Publisher == nil {
+		return nil, errors.New("dead-letter: missing publisher")
+	}
+
+	if opts.Topic == "" && opts.TopicFunc == nil {
+		return nil, fmt.Errorf("%w: dead-letter: missing topic", types.ErrInvalidTopicName)
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			var (
+				err      error
+				attempts int
+			)
+
+			for {
+				attempts++
+
+				if err = next.Process(ctx, topic, payload); err == nil {
+					return nil
+				}
+
+				var backoff *types.BackoffError
+				if opts.PassThroughBackoff && errors.As(err, &backoff) {
+					return err
+				}
+
+				if !types.IsRecoverable(err) || attempts >= opts.MaxAttempts {
+					break
+				}
+
+				if opts.RetryDelay > 0 {
+					select {
+					case <-ctx.Done():
+						return err
+					case <-time.After(opts.RetryDelay):
+					}
+				}
+			}
+
+			msg := NewMessage(topic, payload, err, attempts)
+
+			if dlErr := opts.Publisher.Publish(ctx, opts.topic(topic), msg); dlErr != nil {
+				backoff := types.NewBackoffError("dead-letter publish failed", types.ErrBackoff.RetryAfterSeconds)
+				return fmt.Errorf("%w: %w", backoff, errors.Join(err, dlErr))
+			}
+
+			return nil
+		})
+	}, nil
+}
+
+func (o Options) topic(topic string) string {
+	if o.TopicFunc != nil {
+		return o.TopicFunc(topic)
+	}
+
+	return o.Topic
+}
+
+// NewMessage creates a copy of _payload_ with the dead-letter metadata (see the `MetadataKey*` constants) set.
+func NewMessage(topic string, payload types.Message, err error, attempts int) types.Message {
+	meta := maps.Clone(payload.Metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+
+	meta[MetadataKeyError] = err.Error()
+	meta[MetadataKeyRecoverable] = types.IsRecoverable(err)
+	meta[MetadataKeyAttempts] = attempts
+	meta[MetadataKeyOriginalTopic] = topic
+	meta[MetadataKeyTimestamp] = time.Now().UTC()
+
+	if be, ok := types.AsBridgeError(err); ok && be.IsHttpCodeSet() {
+		meta[MetadataKeyErrorCode] = be.HttpCode
+	}
+
+	payload.Metadata = meta
+
+	return payload
+}
@@ -0,0 +1,144 @@
+package deadletter_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/deadletter"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captured struct {
+	topic   string
+	payload types.Message
+}
+
+func capture(out *[]captured, err error) types.Publisher {
+	return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		*out = append(*out, captured{topic: topic, payload: payload})
+		return err
+	})
+}
+
+func failing(calls *int, err error) types.Subscriber {
+	return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		*calls++
+		return err
+	})
+}
+
+func deadLetter(t *testing.T, opts deadletter.Options) types.SubscriberMiddleware {
+	t.Helper()
+
+	mw, err := deadletter.DeadLetter(opts)
+	require.NoError(t, err)
+
+	return mw
+}
+
+func TestDeadLetter_PermanentErrorIsForwardedDirectly(t *testing.T) {
+	var (
+		out   []captured
+		calls int
+	)
+
+	sub := types.ChainSubscriber(
+		failing(&calls, types.ErrInvalidPayload),
+		deadLetter(t, deadletter.Options{Publisher: capture(&out, nil), Topic: "dlq", MaxAttempts: 3}),
+	)
+
+	err := sub.Process(context.Background(), "devices/1", types.Message{Topic: "devices/1", Payload: []byte("x")})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	require.Len(t, out, 1)
+	assert.Equal(t, "dlq", out[0].topic)
+	assert.Equal(t, "devices/1", out[0].payload.Metadata[deadletter.MetadataKeyOriginalTopic])
+	assert.Equal(t, 1, out[0].payload.Metadata[deadletter.MetadataKeyAttempts])
+	assert.Equal(t, 422, out[0].payload.Metadata[deadletter.MetadataKeyErrorCode])
+	assert.Equal(t, false, out[0].payload.Metadata[deadletter.MetadataKeyRecoverable])
+}
+
+func TestDeadLetter_RecoverableErrorIsRetried(t *testing.T) {
+	var (
+		out   []captured
+		calls int
+	)
+
+	sub := types.ChainSubscriber(
+		failing(&calls, types.ErrServerUnavailable),
+		deadLetter(t, deadletter.Options{
+			Publisher:   capture(&out, nil),
+			TopicFunc:   func(topic string) string { return topic + "/dlq" },
+			MaxAttempts: 3,
+		}),
+	)
+
+	require.NoError(t, sub.Process(context.Background(), "a", types.Message{Topic: "a"}))
+
+	assert.Equal(t, 3, calls)
+	require.Len(t, out, 1)
+	assert.Equal(t, "a/dlq", out[0].topic)
+	assert.Equal(t, 3, out[0].payload.Metadata[deadletter.MetadataKeyAttempts])
+}
+
+func TestDeadLetter_PassThroughBackoffAndPublishFailure(t *testing.T) {
+	var (
+		out   []captured
+		calls int
+	)
+
+	sub := types.ChainSubscriber(
+		failing(&calls, types.ErrBackoff),
+		deadLetter(t, deadletter.Options{Publisher: capture(&out, nil), Topic: "dlq", PassThroughBackoff: true}),
+	)
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", types.Message{}), types.ErrBackoff)
+	assert.Empty(t, out)
+
+	// A backoff created by the transport is not the `types.ErrBackoff` sentinel.
+	calls = 0
+	backoff := types.NewBackoffError("throttled", 5)
+
+	sub = types.ChainSubscriber(
+		failing(&calls, fmt.Errorf("process: %w", backoff)),
+		deadLetter(t, deadletter.Options{Publisher: capture(&out, nil), Topic: "dlq", MaxAttempts: 3, PassThroughBackoff: true}),
+	)
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", types.Message{}), backoff)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, out)
+
+	sub = types.ChainSubscriber(
+		failing(&calls, errors.New("boom")),
+		deadLetter(t, deadletter.Options{Publisher: capture(&out, types.ErrServerNotConnected), Topic: "dlq"}),
+	)
+
+	// The message must be re-delivered when it could not be dead-lettered.
+	err := sub.Process(context.Background(), "a", types.Message{})
+	assert.ErrorIs(t, err, types.ErrServerNotConnected)
+	assert.ErrorContains(t, err, "boom")
+
+	var redeliver *types.BackoffError
+	assert.ErrorAs(t, err, &redeliver)
+}
+
+func TestDeadLetter_ValidatesOptions(t *testing.T) {
+	var out []captured
+
+	_, err := deadletter.DeadLetter(deadletter.Options{Topic: "dlq"})
+	assert.Error(t, err)
+
+	_, err = deadletter.DeadLetter(deadletter.Options{Publisher: capture(&out, nil)})
+	assert.ErrorIs(t, err, types.ErrInvalidTopicName)
+
+	_, err = deadletter.DeadLetter(deadletter.Options{
+		Publisher: capture(&out, nil),
+		TopicFunc: func(topic string) string { return topic + "/dlq" },
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,267 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the `Dispatcher`.
+type Options struct {
+	// Workers is the number of concurrent workers. If zero or less, one worker is used.
+	Workers int
+	// QueueSize is the number of messages that may be queued per worker before backpressure is applied.
+	// If zero or less, the queue is unbuffered.
+	QueueSize int
+	// PartitionKey is the metadata key used to get the partition key of a message. When not set, or the
+	// message lacks the metadata, the topic is used as partition key.
+	PartitionKey string
+	// KeyFunc optionally overrides the partition key resolution.
+	KeyFunc func(topic string, payload types.Message) string
+	// RejectWhenFull makes `Dispatcher.Process` return `types.ErrBackoff` when the worker queue is full
+	// instead of blocking the `SubscriberSource` until there is room.
+	RejectWhenFull bool
+	// OnError is invoked (from the worker) when the `Subscriber` returns an error.
+	OnError func(ctx context.Context, topic string, payload types.Message, err error)
+}
+
+type job struct {
+	ctx          context.Context
+	topic        string
+	payload      types.Message
+	acknowledger *ownership
+}
+
+// ownership wraps the `types.Acknowledger` of a message to track if the next `Subscriber` has taken over
+// the settlement, in which case the `Dispatcher` does not settle it.
+type ownership struct {
+	types.Acknowledger
+	taken atomic.Bool
+}
+
+func (o *ownership) Manual() {
+	o.taken.Store(true)
+	o.Acknowledger.Manual()
+}
+
+func (o *ownership) Ack(ctx context.Context) error {
+	o.taken.Store(true)
+	return o.Acknowledger.Ack(ctx)
+}
+
+func (o *ownership) Nack(ctx context.Context, delay time.Duration) error {
+	o.taken.Store(true)
+	return o.Acknowledger.Nack(ctx, delay)
+}
+
+func (o *ownership) DeadLetter(ctx context.Context, reason error) error {
+	o.taken.Store(true)
+	return o.Acknowledger.DeadLetter(ctx, reason)
+}
+
+// Dispatcher is a `Subscriber` that processes messages on a bounded pool of workers while preserving
+// the order of messages having the same partition key.
+//
+// Messages are assigned to a worker by hashing their partition key, hence all messages with the same
+// key are processed sequentially, in the order received, by the same worker.
+//
+// NOTE: Since processing is asynchronous, `Process` returns as soon as the message is queued and the errors
+// from the next `Subscriber` are reported to `Options.OnError`.
+//
+// When the `SubscriberSource` provides a `types.Acknowledger` (see `types.AcknowledgerFromContext`), the
+// `Dispatcher` takes ownership of the settlement and settles the message once the worker has processed it: a
+// `nil` error acks, a `types.BackoffError` nacks with its _RetryAfterSeconds_ delay and all other errors acks
+// (drops) the message as documented by `types.Subscriber`. If the next `Subscriber` settles (or calls `Manual`)
+// itself, the `Dispatcher` leaves the settlement to it.
+//
+// WARNING: Without a `types.Acknowledger`, the `SubscriberSource` acks the message as soon as it is queued,
+// i.e. before it is processed. Queued messages are lost if the process crashes and a `types.ErrBackoff` from
+// the next `Subscriber` cannot trigger a re-delivery.
+//
+// It implements `io.Closer` and `Close` must be called to drain the queues and stop the workers.
+type Dispatcher struct {
+	next    types.Subscriber
+	opts    Options
+	queues  []chan job
+	mu      sync.RWMutex
+	closed  bool
+	quit    chan struct{}
+	senders sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a new `Dispatcher` that forwards messages to _next_ and starts its workers.
+func NewDispatcher(next types.Subscriber, opts Options) *Dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+
+	d := &Dispatcher{
+		next:   next,
+		opts:   opts,
+		queues: make([]chan job, opts.Workers),
+		quit:   make(chan struct{}),
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan job, opts.QueueSize)
+
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+// Concurrent creates a `SubscriberMiddleware` that wraps the next `Subscriber` in a `Dispatcher`.
+//
+// The created dispatchers are passed to _created_ (if not `nil`) so they can be closed at shutdown.
+func Concurrent(opts Options, created func(d *Dispatcher)) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		d := NewDispatcher(next, opts)
+
+		if created != nil {
+			created(d)
+		}
+
+		return d
+	}
+}
+
+// Process queues the message onto the worker owning its partition key.
+//
+// When the queue is full it blocks until there is room, or _ctx_ is done, unless `Options.RejectWhenFull` is
+// set, then `types.ErrBackoff` is returned. If the `Dispatcher` is closed, `types.ErrServerNotConnected` is returned.
+func (d *Dispatcher) Process(ctx context.Context, topic string, payload types.Message) error {
+	d.mu.RLock()
+
+	if d.closed {
+		d.mu.RUnlock()
+		return types.ErrServerNotConnected
+	}
+
+	// The lock is not held while blocking on the queue so `Close` is not stalled by a full queue.
+	d.senders.Add(1)
+	d.mu.RUnlock()
+
+	defer d.senders.Done()
+
+	j := job{ctx: context.WithoutCancel(ctx), topic: topic, payload: payload}
+
+	if a, ok := types.AcknowledgerFromContext(ctx); ok {
+		j.acknowledger = &ownership{Acknowledger: a}
+		j.ctx = types.ContextWithAcknowledger(j.ctx, j.acknowledger)
+	}
+
+	queue := d.queues[d.worker(topic, payload)]
+
+	if d.opts.RejectWhenFull {
+		select {
+		case queue <- j:
+		default:
+			return types.ErrBackoff
+		}
+	} else {
+		select {
+		case queue <- j:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.quit:
+			return types.ErrServerNotConnected
+		}
+	}
+
+	if j.acknowledger != nil {
+		// Take ownership only once queued, otherwise the `SubscriberSource` settles on the returned error.
+		j.acknowledger.Acknowledger.Manual()
+	}
+
+	return nil
+}
+
+// Close stops accepting new messages, waits for all queued messages to be processed and stops the workers.
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+
+	d.closed = true
+	close(d.quit)
+	d.mu.Unlock()
+
+	// No new senders after closed is set, wait for the blocked ones to give up before closing the queues.
+	d.senders.Wait()
+
+	for _, q := range d.queues {
+		close(q)
+	}
+
+	d.wg.Wait()
+
+	return nil
+}
+
+func (d *Dispatcher) work(queue chan job) {
+	defer d.wg.Done()
+
+	for j := range queue {
+		err := d.next.Process(j.ctx, j.topic, j.payload)
+
+		if err != nil && d.opts.OnError != nil {
+			d.opts.OnError(j.ctx, j.topic, j.payload, err)
+		}
+
+		if j.acknowledger != nil && !j.acknowledger.taken.Load() {
+			settle(j.ctx, j.acknowledger.Acknowledger, err)
+		}
+	}
+}
+
+// settle settles the message according to the `types.Subscriber` contract.
+func settle(ctx context.Context, a types.Acknowledger, err error) {
+	var backoff *types.BackoffError
+	if errors.As(err, &backoff) {
+		_ = a.Nack(ctx, time.Duration(backoff.RetryAfterSeconds)*time.Second)
+		return
+	}
+
+	_ = a.Ack(ctx)
+}
+
+// worker returns the index of the worker that owns the partition key of the message.
+func (d *Dispatcher) worker(topic string, payload types.Message) int {
+	if len(d.queues) == 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(d.key(topic, payload)))
+
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+func (d *Dispatcher) key(topic string, payload types.Message) string {
+	if d.opts.KeyFunc != nil {
+		return d.opts.KeyFunc(topic, payload)
+	}
+
+	if d.opts.PartitionKey != "" && payload.Metadata != nil {
+		if key, ok := payload.Metadata[d.opts.PartitionKey].(string); ok && key != "" {
+			return key
+		}
+	}
+
+	return topic
+}
@@ -0,0 +1,161 @@
+package dispatch_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/ack"
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/dispatch"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_PreservesOrderPerKey(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got = map[string][]int{}
+	)
+
+	d := dispatch.NewDispatcher(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := payload.Metadata["device"].(string)
+		got[key] = append(got[key], payload.Metadata["seq"].(int))
+
+		return nil
+	}), dispatch.Options{Workers: 4, QueueSize: 8, PartitionKey: "device"})
+
+	for seq := range 100 {
+		for dev := range 5 {
+			msg := types.Message{Metadata: map[string]any{"device": fmt.Sprintf("dev-%d", dev), "seq": seq}}
+			require.NoError(t, d.Process(context.Background(), "telemetry", msg))
+		}
+	}
+
+	require.NoError(t, d.Close())
+
+	require.Len(t, got, 5)
+	for key, seqs := range got {
+		require.Len(t, seqs, 100, key)
+		for i, seq := range seqs {
+			assert.Equal(t, i, seq, key)
+		}
+	}
+
+	assert.ErrorIs(t, d.Process(context.Background(), "telemetry", types.Message{}), types.ErrServerNotConnected)
+}
+
+func TestDispatcher_RejectWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	errs := make(chan error, 1)
+
+	d := dispatch.NewDispatcher(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		<-release
+		return types.ErrInvalidPayload
+	}), dispatch.Options{
+		Workers:        1,
+		RejectWhenFull: true,
+		OnError: func(ctx context.Context, topic string, payload types.Message, err error) {
+			errs <- err
+		},
+	})
+
+	// Unbuffered queue: first message is picked up by the worker, which then blocks.
+	require.Eventually(t, func() bool {
+		return d.Process(context.Background(), "a", types.Message{}) == nil
+	}, time.Second, time.Millisecond)
+
+	assert.ErrorIs(t, d.Process(context.Background(), "a", types.Message{}), types.ErrBackoff)
+
+	close(release)
+	assert.ErrorIs(t, <-errs, types.ErrInvalidPayload)
+	require.NoError(t, d.Close())
+}
+
+func TestDispatcher_SettlesAfterProcessing(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		settled []string
+	)
+
+	record := func(what string) { mu.Lock(); defer mu.Unlock(); settled = append(settled, what) }
+
+	release := make(chan struct{})
+
+	d := dispatch.NewDispatcher(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		<-release
+
+		if topic == "backoff" {
+			return types.NewBackoffError("throttled", 5)
+		}
+
+		return nil
+	}), dispatch.Options{Workers: 1, QueueSize: 2})
+
+	tracker := func(topic string) *ack.Tracker {
+		return ack.NewTracker(ack.Funcs{
+			Ack: func(ctx context.Context) error { record(topic + ":ack"); return nil },
+			Nack: func(ctx context.Context, delay time.Duration) error {
+				record(fmt.Sprintf("%s:nack:%s", topic, delay))
+				return nil
+			},
+		})
+	}
+
+	ok, backoff := tracker("ok"), tracker("backoff")
+
+	require.NoError(t, d.Process(types.ContextWithAcknowledger(context.Background(), ok), "ok", types.Message{}))
+	require.NoError(t, d.Process(types.ContextWithAcknowledger(context.Background(), backoff), "backoff", types.Message{}))
+
+	// The source must not settle since the dispatcher took ownership.
+	assert.True(t, ok.IsManual())
+	assert.True(t, backoff.IsManual())
+	assert.False(t, ok.IsSettled())
+
+	close(release)
+	require.NoError(t, d.Close())
+
+	assert.Equal(t, []string{"ok:ack", "backoff:nack:5s"}, settled)
+}
+
+func TestDispatcher_CloseDoesNotHangOnBlockedSender(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	d := dispatch.NewDispatcher(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		<-release
+		return nil
+	}), dispatch.Options{Workers: 1, QueueSize: 1})
+
+	// One in the worker, one in the queue and the third blocks on the send.
+	require.NoError(t, d.Process(context.Background(), "a", types.Message{}))
+	require.NoError(t, d.Process(context.Background(), "a", types.Message{}))
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- d.Process(context.Background(), "a", types.Message{}) }()
+
+	closed := make(chan error, 1)
+	go func() { closed <- d.Close() }()
+
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, types.ErrServerNotConnected)
+	case <-time.After(time.Second):
+		t.Fatal("blocked sender was not released by Close")
+	}
+
+	release <- struct{}{}
+	release <- struct{}{}
+
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Close hung")
+	}
+}
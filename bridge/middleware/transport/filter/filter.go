@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mariotoffia/gobridge/bridge/expr"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Route sends messages matching _When_ to _Subscriber_ instead of the next in chain.
+type Route struct {
+	// When is the `expr.Expression` predicate, e.g. `payload.temp > 40 && metadata.region == "eu"`.
+	When string
+	// Subscriber receives the matching messages.
+	Subscriber types.Subscriber
+}
+
+// Options configures the filter middleware.
+//
+// All predicates are `expr.Expression` sources evaluated over the variables from `expr.MessageVars`
+// (_topic_, _payload_, _metadata_ and _qos_).
+type Options struct {
+	// Filter is the predicate a message must satisfy to be passed on, otherwise it is dropped. If empty,
+	// all messages are passed on (or routed).
+	Filter string
+	// Routes are evaluated, in order, on the messages passing the _Filter_. The first matching route
+	// receives the message. If none matches, it is passed on to the next in chain.
+	Routes []Route
+	// RejectInvalid returns a `types.ErrInvalidPayload` wrapped error when the payload is not JSON or a
+	// predicate fails to evaluate. Otherwise, a non JSON payload evaluates as `null` and failing
+	// predicates are treated as not matching.
+	RejectInvalid bool
+	// OnDropped is an optional callback invoked for each dropped message.
+	OnDropped func(ctx context.Context, topic string, payload types.Message)
+}
+
+type route struct {
+	when       *expr.Expression
+	subscriber types.Subscriber
+}
+
+// Filter creates a `SubscriberMiddleware` that drops or routes messages based on predicates over the topic,
+// metadata and JSON payload fields.
+//
+// The predicates are compiled once and an error is returned if any is invalid.
+func Filter(opts Options) (types.SubscriberMiddleware, error) {
+	var (
+		filter *expr.Expression
+		routes []route
+		err    error
+	)
+
+	if opts.Filter != "" {
+		if filter, err = expr.Compile(opts.Filter); err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+	}
+
+	for i, r := range opts.Routes {
+		when, err := expr.Compile(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("filter route %d: %w", i, err)
+		}
+
+		if r.Subscriber == nil {
+			return nil, fmt.Errorf("filter route %d: missing subscriber", i)
+		}
+
+		routes = append(routes, route{when: when, subscriber: r.Subscriber})
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			vars, err := expr.MessageVars(topic, payload)
+			if err != nil {
+				if opts.RejectInvalid {
+					return err
+				}
+
+				raw := payload
+				raw.Payload = nil
+				vars, _ = expr.MessageVars(topic, raw)
+			}
+
+			if filter != nil {
+				ok, err := opts.eval(filter, vars)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					if opts.OnDropped != nil {
+						opts.OnDropped(ctx, topic, payload)
+					}
+
+					return nil
+				}
+			}
+
+			for _, r := range routes {
+				ok, err := opts.eval(r.when, vars)
+				if err != nil {
+					return err
+				}
+
+				if ok {
+					return r.subscriber.Process(ctx, topic, payload)
+				}
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}, nil
+}
+
+func (o Options) eval(e *expr.Expression, vars map[string]any) (bool, error) {
+	ok, err := e.EvalBool(vars)
+	if err != nil {
+		if o.RejectInvalid {
+			return false, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+		}
+
+		return false, nil
+	}
+
+	return ok, nil
+}
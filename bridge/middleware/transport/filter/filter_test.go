@@ -0,0 +1,60 @@
+package filter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/filter"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(out *[]string, name string) types.Subscriber {
+	return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		*out = append(*out, name+":"+string(payload.Payload))
+		return nil
+	})
+}
+
+func TestFilter_DropsAndRoutes(t *testing.T) {
+	var (
+		got     []string
+		dropped int
+	)
+
+	mw, err := filter.Filter(filter.Options{
+		Filter: `metadata.region == "eu"`,
+		Routes: []filter.Route{{When: `payload.temp > 40`, Subscriber: collect(&got, "alarm")}},
+		OnDropped: func(ctx context.Context, topic string, payload types.Message) {
+			dropped++
+		},
+	})
+	require.NoError(t, err)
+
+	sub := types.ChainSubscriber(collect(&got, "next"), mw)
+	eu := map[string]any{"region": "eu"}
+
+	require.NoError(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`{"temp":41}`), Metadata: eu}))
+	require.NoError(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`{"temp":20}`), Metadata: eu}))
+	require.NoError(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`{"temp":41}`)}))
+	require.NoError(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`raw`), Metadata: eu}))
+
+	assert.Equal(t, []string{`alarm:{"temp":41}`, `next:{"temp":20}`, `next:raw`}, got)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestFilter_RejectInvalid(t *testing.T) {
+	mw, err := filter.Filter(filter.Options{Filter: `payload.temp > 40`, RejectInvalid: true})
+	require.NoError(t, err)
+
+	var got []string
+	sub := types.ChainSubscriber(collect(&got, "next"), mw)
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`raw`)}), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "t", types.Message{Payload: []byte(`{"temp":"hot"}`)}), types.ErrInvalidPayload)
+	assert.Empty(t, got)
+
+	_, err = filter.Filter(filter.Options{Filter: `payload.temp >`})
+	assert.Error(t, err)
+}
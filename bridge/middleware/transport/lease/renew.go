@@ -0,0 +1,134 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// DefaultExtension is the default duration the lease is extended by on each renewal.
+	DefaultExtension = 30 * time.Second
+	// DefaultMaxDuration is the default max total time the lease is renewed.
+	DefaultMaxDuration = time.Hour
+)
+
+// Options configures the lease renewal middleware.
+type Options struct {
+	// Extension is the duration the lease is extended by on each renewal. If zero, `DefaultExtension` is used.
+	Extension time.Duration
+	// Interval is the time between renewals. It must be shorter than _Extension_, if zero, half the
+	// _Extension_ is used.
+	Interval time.Duration
+	// MaxDuration is the max total time, from when processing started, the lease is renewed. Thereafter the
+	// lease is left to expire. If zero, `DefaultMaxDuration` is used.
+	MaxDuration time.Duration
+	// OnRenewed is an optional callback invoked after each successful renewal.
+	OnRenewed func(ctx context.Context, topic string, payload types.Message)
+}
+
+// SubscriberRenewal creates a `SubscriberMiddleware` that periodically extends the lease of the message, using the
+// `types.Acknowledger` in the context, while the next `Subscriber.Process` is running.
+//
+// If a renewal fails, the context passed to the next `Subscriber` is cancelled and a `types.BackoffError`, with
+// no retry delay, wrapping the renewal error is returned. Hence the `SubscriberSource` nacks the message for
+// immediate re-delivery instead of acking (dropping) it. Renewal stops silently once the message is settled or
+// when the transport cannot extend the lease.
+//
+// Messages without an `types.Acknowledger` are passed through untouched.
+//
+// NOTE: The lease is only renewed until `Process` returns, a `Subscriber` that continues processing asynchronously
+// must renew the lease itself.
+func SubscriberRenewal(opts Options) types.SubscriberMiddleware {
+	if opts.Extension <= 0 {
+		opts.Extension = DefaultExtension
+	}
+
+	if opts.Interval <= 0 || opts.Interval >= opts.Extension {
+		opts.Interval = opts.Extension / 2
+	}
+
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = DefaultMaxDuration
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			acknowledger, ok := types.AcknowledgerFromContext(ctx)
+			if !ok {
+				return next.Process(ctx, topic, payload)
+			}
+
+			ctx, cancel := context.WithCancelCause(ctx)
+			defer cancel(nil)
+
+			var (
+				renewErr error
+				done     = make(chan struct{})
+				stopped  = make(chan struct{})
+			)
+
+			go func() {
+				defer close(stopped)
+
+				if renewErr = opts.renew(ctx, done, acknowledger, topic, payload); renewErr != nil {
+					cancel(renewErr)
+				}
+			}()
+
+			err := next.Process(ctx, topic, payload)
+
+			close(done)
+			<-stopped
+
+			if renewErr != nil {
+				return renewErr
+			}
+
+			return err
+		})
+	}
+}
+
+// renew extends the lease until _done_ is closed and returns the error of a failed renewal.
+func (o Options) renew(
+	ctx context.Context,
+	done <-chan struct{},
+	acknowledger types.Acknowledger,
+	topic string,
+	payload types.Message,
+) error {
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(o.MaxDuration)
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if now.After(deadline) {
+				return nil
+			}
+
+			err := acknowledger.ExtendLease(ctx, o.Extension)
+
+			switch {
+			case err == nil:
+				if o.OnRenewed != nil {
+					o.OnRenewed(ctx, topic, payload)
+				}
+			case errors.Is(err, types.ErrAlreadySettled), errors.Is(err, types.ErrProtocolMismatch):
+				return nil
+			default:
+				return fmt.Errorf("%w: %w", types.NewBackoffError("lease renewal failed", 0), err)
+			}
+		}
+	}
+}
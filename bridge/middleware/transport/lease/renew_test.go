@@ -0,0 +1,85 @@
+package lease_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/ack"
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/lease"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberRenewal_RenewsWhileProcessing(t *testing.T) {
+	var renewals atomic.Int32
+
+	tracker := ack.NewTracker(ack.Funcs{
+		ExtendLease: func(ctx context.Context, d time.Duration) error {
+			assert.Equal(t, 40*time.Millisecond, d)
+			renewals.Add(1)
+
+			return nil
+		},
+	})
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}), lease.SubscriberRenewal(lease.Options{Extension: 40 * time.Millisecond, Interval: 10 * time.Millisecond}))
+
+	require.NoError(t, sub.Process(types.ContextWithAcknowledger(context.Background(), tracker), "images", types.Message{}))
+
+	count := renewals.Load()
+	assert.GreaterOrEqual(t, count, int32(5))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, count, renewals.Load(), "renewal must stop when Process returns")
+}
+
+func TestSubscriberRenewal_CancelsOnFailure(t *testing.T) {
+	lost := errors.New("lock lost")
+
+	var (
+		acked  atomic.Bool
+		nacked atomic.Bool
+		delay  time.Duration
+	)
+
+	tracker := ack.NewTracker(ack.Funcs{
+		Ack: func(ctx context.Context) error { acked.Store(true); return nil },
+		Nack: func(ctx context.Context, d time.Duration) error {
+			nacked.Store(true)
+			delay = d
+
+			return nil
+		},
+		ExtendLease: func(ctx context.Context, d time.Duration) error { return lost },
+	})
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}), lease.SubscriberRenewal(lease.Options{Extension: 20 * time.Millisecond}))
+
+	ctx := types.ContextWithAcknowledger(context.Background(), tracker)
+
+	err := sub.Process(ctx, "images", types.Message{})
+	assert.ErrorIs(t, err, lost)
+
+	var backoff *types.BackoffError
+	require.ErrorAs(t, err, &backoff)
+
+	// The message must be re-delivered, not dropped.
+	require.NoError(t, tracker.Settle(ctx, err))
+	assert.False(t, acked.Load(), "a message whose lease was lost must not be acked")
+	assert.True(t, nacked.Load())
+	assert.Zero(t, delay)
+}
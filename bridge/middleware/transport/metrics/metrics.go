@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// InstrumentationName is the name of the `metric.Meter` used when `Options.Meter` is not set.
+const InstrumentationName = "github.com/mariotoffia/gobridge/bridge/middleware/transport/metrics"
+
+var (
+	// DefaultDurationBuckets are the histogram buckets, in seconds, for latencies.
+	DefaultDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the histogram buckets, in bytes, for payload sizes.
+	DefaultSizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+)
+
+// Options configures the metrics middlewares.
+type Options struct {
+	// Meter creates the instruments. If `nil`, the meter named `InstrumentationName` is fetched from the
+	// global `otel.GetMeterProvider` (see `metrics.NewPrometheus` for a _Prometheus_ exposition).
+	Meter metric.Meter
+	// ConnectionID is recorded as the _messaging.connection_ attribute.
+	ConnectionID string
+	// TransportType is recorded as the _messaging.system_ attribute.
+	TransportType types.TransportType
+	// TopicPattern maps the topic onto the _messaging.destination.template_ attribute, e.g. the subscribed
+	// wildcard topic of a published topic. If `nil`, `SubscriberMetrics` uses the topic it is registered on
+	// (the subscription pattern) and `PublishMetrics` do not record any topic.
+	TopicPattern func(topic string) string
+	// ConcreteTopic records the concrete `Message.Topic` as the _messaging.destination.name_ attribute.
+	//
+	// NOTE: This gives one time series per topic, i.e. unbounded cardinality with e.g. device ids in the topics.
+	ConcreteTopic bool
+}
+
+type recorder struct {
+	opts      Options
+	subscribe bool
+	count     metric.Int64Counter
+	duration  metric.Float64Histogram
+	size      metric.Int64Histogram
+}
+
+// PublishMetrics creates a `PublisherMiddleware` that records the following metrics using `Options.Meter`:
+//
+// - bridge.publish.messages: number of publishes by _status_ (ok/error), _error.code_ (`types.BridgeError.HttpCode`)
+// and _error.recoverable_.
+//
+// - bridge.publish.duration: histogram of publish latencies in seconds.
+//
+// - bridge.publish.payload.size: histogram of payload sizes in bytes.
+//
+// An error is returned if the instruments cannot be created, e.g. when a name is already registered as a
+// different kind of instrument.
+func PublishMetrics(opts Options) (types.PublisherMiddleware, error) {
+	rec, err := newRecorder(opts, "publish")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			start := time.Now()
+			err := next.Publish(ctx, topic, payload)
+
+			rec.record(ctx, topic, payload, start, err)
+
+			return err
+		})
+	}, nil
+}
+
+// SubscriberMetrics creates a `SubscriberMiddleware` that records the same metrics as `PublishMetrics` but
+// prefixed with bridge.process instead of bridge.publish.
+func SubscriberMetrics(opts Options) (types.SubscriberMiddleware, error) {
+	rec, err := newRecorder(opts, "process")
+	if err != nil {
+		return nil, err
+	}
+
+	rec.subscribe = true
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			start := time.Now()
+			err := next.Process(ctx, topic, payload)
+
+			rec.record(ctx, topic, payload, start, err)
+
+			return err
+		})
+	}, nil
+}
+
+func newRecorder(opts Options, op string) (*recorder, error) {
+	if opts.Meter == nil {
+		opts.Meter = otel.GetMeterProvider().Meter(InstrumentationName)
+	}
+
+	count, err := opts.Meter.Int64Counter(
+		"bridge."+op+".messages",
+		metric.WithDescription("Number of "+op+" operations."), metric.WithUnit("{message}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	duration, err := opts.Meter.Float64Histogram(
+		"bridge."+op+".duration",
+		metric.WithDescription("Latency of "+op+" operations."), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(DefaultDurationBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	size, err := opts.Meter.Int64Histogram(
+		"bridge."+op+".payload.size",
+		metric.WithDescription("Payload size of "+op+" operations."), metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(DefaultSizeBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	return &recorder{opts: opts, count: count, duration: duration, size: size}, nil
+}
+
+func (r *recorder) record(ctx context.Context, topic string, payload types.Message, start time.Time, err error) {
+	attrs := r.attributes(topic, payload)
+
+	r.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	r.size.Record(ctx, int64(len(payload.Payload)), metric.WithAttributes(attrs...))
+
+	if err == nil {
+		r.count.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("status", "ok"))...))
+		return
+	}
+
+	var code int
+	if be, ok := types.AsBridgeError(err); ok {
+		code = be.HttpCode
+	}
+
+	r.count.Add(ctx, 1, metric.WithAttributes(append(
+		attrs,
+		attribute.String("status", "error"),
+		attribute.Int("error.code", code),
+		attribute.Bool("error.recoverable", types.IsRecoverable(err)),
+	)...))
+}
+
+func (r *recorder) attributes(topic string, payload types.Message) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	if r.opts.ConnectionID != "" {
+		attrs = append(attrs, attribute.String("messaging.connection", r.opts.ConnectionID))
+	}
+
+	if r.opts.TransportType != "" {
+		attrs = append(attrs, attribute.String("messaging.system", string(r.opts.TransportType)))
+	}
+
+	switch {
+	case r.opts.TopicPattern != nil:
+		attrs = append(attrs, attribute.String("messaging.destination.template", r.opts.TopicPattern(topic)))
+	case r.subscribe:
+		attrs = append(attrs, attribute.String("messaging.destination.template", topic))
+	}
+
+	if r.opts.ConcreteTopic {
+		concrete := payload.Topic
+		if concrete == "" {
+			concrete = topic
+		}
+
+		attrs = append(attrs, attribute.String("messaging.destination.name", concrete))
+	}
+
+	return attrs
+}
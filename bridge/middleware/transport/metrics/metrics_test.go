@@ -0,0 +1,125 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/metrics"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	out := map[string]metricdata.Aggregation{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+
+	return out
+}
+
+func TestPublishMetrics_RecordsOutcome(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	var fail error
+
+	metrics, err := mw.PublishMetrics(mw.Options{
+		Meter:         meter,
+		ConnectionID:  "mqtt-1",
+		TransportType: types.TransportTypeMQTT,
+		TopicPattern:  func(string) string { return "devices/+/telemetry" },
+	})
+	require.NoError(t, err)
+
+	pub := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			return fail
+		}),
+		metrics,
+	)
+
+	_ = pub.Publish(context.Background(), "devices/1/telemetry", types.Message{Payload: []byte("abc")})
+
+	fail = types.ErrBrokerOverload
+	_ = pub.Publish(context.Background(), "devices/2/telemetry", types.Message{})
+
+	data := collect(t, reader)
+
+	base := []attribute.KeyValue{
+		attribute.String("messaging.connection", "mqtt-1"),
+		attribute.String("messaging.system", "MQTT"),
+		attribute.String("messaging.destination.template", "devices/+/telemetry"),
+	}
+
+	count, ok := data["bridge.publish.messages"].(metricdata.Sum[int64])
+	require.True(t, ok)
+
+	values := map[attribute.Set]int64{}
+	for _, dp := range count.DataPoints {
+		values[dp.Attributes] = dp.Value
+	}
+
+	assert.Equal(t, int64(1), values[attribute.NewSet(append(base, attribute.String("status", "ok"))...)])
+	assert.Equal(t, int64(1), values[attribute.NewSet(append(
+		base,
+		attribute.String("status", "error"),
+		attribute.Int("error.code", 503),
+		attribute.Bool("error.recoverable", true),
+	)...)])
+
+	duration, ok := data["bridge.publish.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, duration.DataPoints, 1)
+	assert.Equal(t, uint64(2), duration.DataPoints[0].Count)
+	assert.Equal(t, mw.DefaultDurationBuckets, duration.DataPoints[0].Bounds)
+}
+
+func TestSubscriberMetrics_LabelsSubscriptionPattern(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	for _, concrete := range []bool{false, true} {
+		metrics, err := mw.SubscriberMetrics(mw.Options{Meter: meter, ConcreteTopic: concrete})
+		require.NoError(t, err)
+
+		sub := types.ChainSubscriber(
+			types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+				return nil
+			}),
+			metrics,
+		)
+
+		for _, id := range []string{"1", "2"} {
+			require.NoError(t, sub.Process(context.Background(), "devices/+", types.Message{Topic: "devices/" + id}))
+		}
+	}
+
+	size, ok := collect(t, reader)["bridge.process.payload.size"].(metricdata.Histogram[int64])
+	require.True(t, ok)
+
+	var series []attribute.Set
+	for _, dp := range size.DataPoints {
+		series = append(series, dp.Attributes)
+	}
+
+	// One series for the pattern and one per concrete topic when opted in.
+	pattern := attribute.String("messaging.destination.template", "devices/+")
+	assert.ElementsMatch(t, []attribute.Set{
+		attribute.NewSet(pattern),
+		attribute.NewSet(pattern, attribute.String("messaging.destination.name", "devices/1")),
+		attribute.NewSet(pattern, attribute.String("messaging.destination.name", "devices/2")),
+	}, series)
+}
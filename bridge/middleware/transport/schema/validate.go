@@ -0,0 +1,87 @@
+package schema
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/deadletter"
+	"github.com/mariotoffia/gobridge/bridge/schema"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the schema validation middlewares.
+type Options struct {
+	// Registry holds the schemas per topic pattern.
+	Registry *schema.Registry
+	// RequireSchema rejects messages on topics without any registered schema. Otherwise, those are passed on.
+	RequireSchema bool
+	// DeadLetter is an optional `types.Publisher` that receives the invalid messages (with the metadata
+	// from `deadletter.NewMessage`). When set and the dead-letter publish succeeds, `nil` is returned, otherwise
+	// the validation error joined with the dead-letter publish error.
+	DeadLetter types.Publisher
+	// DeadLetterTopic is the topic to publish invalid messages to on _DeadLetter_.
+	DeadLetterTopic string
+}
+
+// PublishValidation creates a `PublisherMiddleware` that validates the JSON payload against all schemas
+// registered on a pattern matching the _topic_.
+//
+// Invalid payloads are rejected with a `*schema.ValidationError` that wraps `types.ErrInvalidPayload` and
+// includes the failing JSON path, or dead-lettered if `Options.DeadLetter` is set.
+func PublishValidation(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := opts.validate(ctx, topic, payload); err != nil {
+				return opts.reject(ctx, topic, payload, err)
+			}
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberValidation creates a `SubscriberMiddleware` that validates the JSON payload in the same
+// manner as `PublishValidation` before it is processed.
+func SubscriberValidation(opts Options) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := opts.validate(ctx, topic, payload); err != nil {
+				return opts.reject(ctx, topic, payload, err)
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+func (o Options) validate(_ context.Context, topic string, payload types.Message) error {
+	schemas := o.Registry.Lookup(topic)
+
+	if len(schemas) == 0 {
+		if o.RequireSchema {
+			return &schema.ValidationError{Path: "$", Reason: "no schema registered for topic " + topic}
+		}
+
+		return nil
+	}
+
+	for _, s := range schemas {
+		if err := s.ValidateJSON(payload.Payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o Options) reject(ctx context.Context, topic string, payload types.Message, err error) error {
+	if o.DeadLetter == nil {
+		return err
+	}
+
+	if dlErr := o.DeadLetter.Publish(ctx, o.DeadLetterTopic, deadletter.NewMessage(topic, payload, err, 1)); dlErr != nil {
+		return errors.Join(err, dlErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/deadletter"
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/schema"
+	"github.com/mariotoffia/gobridge/bridge/schema"
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberValidation_RejectsAndDeadLetters(t *testing.T) {
+	s, err := schema.Compile([]byte(`{"type": "object", "required": ["temp"], "properties": {"temp": {"type": "number"}}}`))
+	require.NoError(t, err)
+
+	reg := schema.NewRegistry(topic.SyntaxMQTT)
+	require.NoError(t, reg.Register("devices/+/telemetry", s))
+
+	var processed int
+
+	next := types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		processed++
+		return nil
+	})
+
+	sub := types.ChainSubscriber(next, mw.SubscriberValidation(mw.Options{Registry: reg}))
+
+	require.NoError(t, sub.Process(context.Background(), "devices/1/telemetry", types.Message{Payload: []byte(`{"temp": 1}`)}))
+	require.NoError(t, sub.Process(context.Background(), "devices/1/state", types.Message{Payload: []byte(`x`)}))
+
+	err = sub.Process(context.Background(), "devices/1/telemetry", types.Message{Payload: []byte(`{"temp": "1"}`)})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+	assert.ErrorContains(t, err, "$.temp")
+	assert.Equal(t, 2, processed)
+
+	var dead []types.Message
+
+	sub = types.ChainSubscriber(next, mw.SubscriberValidation(mw.Options{
+		Registry:      reg,
+		RequireSchema: true,
+		DeadLetter: types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			dead = append(dead, payload)
+			return nil
+		}),
+		DeadLetterTopic: "dlq",
+	}))
+
+	require.NoError(t, sub.Process(context.Background(), "devices/1/state", types.Message{Payload: []byte(`{}`)}))
+	require.Len(t, dead, 1)
+	assert.Equal(t, "devices/1/state", dead[0].Metadata[deadletter.MetadataKeyOriginalTopic])
+	assert.Equal(t, 422, dead[0].Metadata[deadletter.MetadataKeyErrorCode])
+	assert.Equal(t, 2, processed)
+}
+
+func TestSubscriberValidation_DeadLetterFailureKeepsValidationError(t *testing.T) {
+	s, err := schema.Compile([]byte(`{"type": "object", "required": ["temp"]}`))
+	require.NoError(t, err)
+
+	reg := schema.NewRegistry(topic.SyntaxMQTT)
+	require.NoError(t, reg.Register("devices/#", s))
+
+	sub := types.ChainSubscriber(
+		types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error { return nil }),
+		mw.SubscriberValidation(mw.Options{
+			Registry: reg,
+			DeadLetter: types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+				return types.ErrServerUnavailable
+			}),
+			DeadLetterTopic: "dlq",
+		}),
+	)
+
+	err = sub.Process(context.Background(), "devices/1", types.Message{Payload: []byte(`{}`)})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+	assert.ErrorIs(t, err, types.ErrServerUnavailable)
+	assert.ErrorContains(t, err, "$.temp")
+}
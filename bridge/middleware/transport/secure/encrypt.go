@@ -0,0 +1,175 @@
+package secure
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyEncryption is the metadata key holding the payload encryption algorithm, i.e. `EncryptionAESGCM`.
+	MetadataKeyEncryption = "encryption"
+	// MetadataKeyEncryptionKeyID is the metadata key holding the id of the key-encryption key that wrapped the data key.
+	MetadataKeyEncryptionKeyID = "encryption-key-id"
+	// MetadataKeyDataKey is the metadata key holding the base64 encoded, wrapped, data key.
+	MetadataKeyDataKey = "encryption-data-key"
+)
+
+// EncryptionAESGCM is AES-256-GCM envelope encryption with a AES-GCM wrapped data key.
+const EncryptionAESGCM = "aes-256-gcm"
+
+// EncryptOptions configures the encryption middlewares.
+type EncryptOptions struct {
+	// Keys resolves the key-encryption keys. They must be 16, 24 or 32 bytes long AES keys.
+	Keys *Keys
+	// Optional, when `true`, lets un-encrypted messages pass through `SubscriberDecrypt`. Otherwise they are
+	// rejected with `types.ErrInvalidPayload`.
+	Optional bool
+}
+
+// PublishEncrypt creates a `PublisherMiddleware` that encrypts the payload using envelope encryption.
+//
+// A random data key encrypts the payload with AES-256-GCM and is itself wrapped, using AES-GCM, by the active
+// key-encryption key from `EncryptOptions.Keys`. The wrapped data key and the key id are carried in the metadata
+// (see the `MetadataKey*` constants) and the payload is the nonce followed by the cipher text.
+func PublishEncrypt(opts EncryptOptions) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			kek, err := opts.Keys.Active()
+			if err != nil {
+				return err
+			}
+
+			dataKey := make([]byte, 32)
+			if _, err := rand.Read(dataKey); err != nil {
+				return err
+			}
+
+			wrapped, err := seal(kek.Key, dataKey, []byte(kek.KeyID))
+			if err != nil {
+				return err
+			}
+
+			data, err := seal(dataKey, payload.Payload, nil)
+			if err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			meta[MetadataKeyEncryption] = EncryptionAESGCM
+			meta[MetadataKeyEncryptionKeyID] = kek.KeyID
+			meta[MetadataKeyDataKey] = base64.StdEncoding.EncodeToString(wrapped)
+
+			payload.Payload = data
+			payload.Metadata = meta
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberDecrypt creates a `SubscriberMiddleware` that decrypts payloads encrypted by `PublishEncrypt` and
+// removes the encryption metadata.
+//
+// Tampered payloads, unknown keys or algorithms are rejected with `types.ErrInvalidPayload`.
+func SubscriberDecrypt(opts EncryptOptions) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			alg, _ := payload.Metadata[MetadataKeyEncryption].(string)
+
+			if alg == "" && opts.Optional {
+				return next.Process(ctx, topic, payload)
+			}
+
+			if alg != EncryptionAESGCM {
+				return fmt.Errorf("%w: unsupported or missing encryption %q", types.ErrInvalidPayload, alg)
+			}
+
+			keyID, _ := payload.Metadata[MetadataKeyEncryptionKeyID].(string)
+			encoded, _ := payload.Metadata[MetadataKeyDataKey].(string)
+
+			wrapped, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("%w: invalid data key: %w", types.ErrInvalidPayload, err)
+			}
+
+			kek, err := opts.Keys.ByID(keyID)
+			if err != nil {
+				return err
+			}
+
+			dataKey, err := open(kek.Key, wrapped, []byte(kek.KeyID))
+			if err != nil {
+				return err
+			}
+
+			data, err := open(dataKey, payload.Payload, nil)
+			if err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			delete(meta, MetadataKeyEncryption)
+			delete(meta, MetadataKeyEncryptionKeyID)
+			delete(meta, MetadataKeyDataKey)
+
+			payload.Payload = data
+			payload.Metadata = meta
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+// seal encrypts _plain_ using AES-GCM and returns the nonce followed by the cipher text.
+func seal(key, plain, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plain, additional), nil
+}
+
+// open decrypts _data_ (as returned by `seal`).
+func open(key, data, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: cipher text too short", types.ErrInvalidPayload)
+	}
+
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+	}
+
+	return cipher.NewGCM(block)
+}
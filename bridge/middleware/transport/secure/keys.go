@@ -0,0 +1,107 @@
+package secure
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Keys resolves `types.KeyCredentials` from a `types.CredentialsRepository`. Resolved keys are cached by URI
+// until they expire (see `Keys.WithTTL`) or are invalidated (see `Keys.Invalidate`).
+type Keys struct {
+	repository types.CredentialsRepository
+	uri        string
+	uriFunc    func(keyID string) string
+	ttl        time.Duration
+	cache      sync.Map // uri -> cachedKey
+}
+
+type cachedKey struct {
+	key     *types.KeyCredentials
+	expires time.Time
+}
+
+// NewKeys creates a new `Keys` where _uri_ is the URI of the active key, used when publishing.
+//
+// The optional _uriFunc_ resolves the URI of a key by its id when receiving, e.g. to accept messages protected
+// with a rotated key. If `nil`, the key at _uri_ is used and its `types.KeyCredentials.KeyID` must match.
+func NewKeys(repository types.CredentialsRepository, uri string, uriFunc func(keyID string) string) *Keys {
+	return &Keys{repository: repository, uri: uri, uriFunc: uriFunc}
+}
+
+// WithTTL makes resolved keys expire after _ttl_, e.g. to pick up rotated keys. A zero _ttl_ caches the keys
+// until invalidated.
+func (k *Keys) WithTTL(ttl time.Duration) *Keys {
+	k.ttl = ttl
+	return k
+}
+
+// Invalidate removes the keys at _uris_ from the cache or, when none are given, all cached keys. They are
+// resolved from the repository again when next used.
+func (k *Keys) Invalidate(uris ...string) {
+	if len(uris) == 0 {
+		k.cache.Clear()
+		return
+	}
+
+	for _, uri := range uris {
+		k.cache.Delete(uri)
+	}
+}
+
+// Active returns the key at the active URI.
+func (k *Keys) Active() (*types.KeyCredentials, error) {
+	return k.resolve(k.uri)
+}
+
+// ByID returns the key with the id _keyID_.
+//
+// If the key cannot be found, a `types.ErrInvalidPayload` wrapped error is returned since the message cannot
+// be verified nor decrypted.
+func (k *Keys) ByID(keyID string) (*types.KeyCredentials, error) {
+	uri := k.uri
+	if k.uriFunc != nil {
+		uri = k.uriFunc(keyID)
+	}
+
+	key, err := k.resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.KeyID != keyID {
+		return nil, fmt.Errorf("%w: unknown key id %q", types.ErrInvalidPayload, keyID)
+	}
+
+	return key, nil
+}
+
+func (k *Keys) resolve(uri string) (*types.KeyCredentials, error) {
+	if v, ok := k.cache.Load(uri); ok {
+		cached := v.(cachedKey)
+		if cached.expires.IsZero() || time.Now().Before(cached.expires) {
+			return cached.key, nil
+		}
+	}
+
+	creds, err := k.repository.GetCredentials(uri)
+	if err != nil {
+		return nil, fmt.Errorf("resolve key %q: %w", uri, err)
+	}
+
+	key, ok := creds.Key()
+	if !ok {
+		return nil, fmt.Errorf("%w: no key credentials at %q", types.ErrNotFound, uri)
+	}
+
+	cached := cachedKey{key: key}
+	if k.ttl > 0 {
+		cached.expires = time.Now().Add(k.ttl)
+	}
+
+	k.cache.Store(uri, cached)
+
+	return key, nil
+}
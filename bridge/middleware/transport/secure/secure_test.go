@@ -0,0 +1,251 @@
+package secure_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/secure"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyRepo map[string]types.KeyCredentials
+
+func (keyRepo) GetScheme() string    { return "mem" }
+func (keyRepo) GetNamespace() string { return "" }
+func (r keyRepo) GetCredentials(serverURI string) (*types.Credentials, error) {
+	key, ok := r[serverURI]
+	if !ok {
+		return nil, types.ErrNotFound
+	}
+
+	return &types.Credentials{Type: []types.CredentialsType{types.CredentialsTypeKey}, Credentials: []any{key}}, nil
+}
+
+func random(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	return b
+}
+
+func TestEncryptAndSign_RoundTrip(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	kek := types.KeyCredentials{KeyID: "kek-1", Key: random(t, 32)}
+
+	publisherRepo := keyRepo{"mem://kek": kek, "mem://sign": {KeyID: "sig-1", Key: private}}
+	subscriberRepo := keyRepo{"mem://kek": kek, "mem://sign": {KeyID: "sig-1", Key: public}}
+
+	var got types.Message
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = payload
+		return nil
+	}),
+		secure.SubscriberVerify(secure.SignOptions{
+			Keys: secure.NewKeys(subscriberRepo, "mem://sign", nil), Algorithm: secure.SignatureEd25519,
+		}),
+		secure.SubscriberDecrypt(secure.EncryptOptions{Keys: secure.NewKeys(subscriberRepo, "mem://kek", nil)}),
+	)
+
+	var wire types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return nil
+	}),
+		secure.PublishEncrypt(secure.EncryptOptions{Keys: secure.NewKeys(publisherRepo, "mem://kek", nil)}),
+		secure.PublishSign(secure.SignOptions{Keys: secure.NewKeys(publisherRepo, "mem://sign", nil)}),
+	)
+
+	require.NoError(t, pub.Publish(context.Background(), "a", types.Message{Payload: []byte("secret"), Metadata: map[string]any{"site": "a"}}))
+
+	assert.NotContains(t, string(wire.Payload), "secret")
+	assert.Equal(t, "kek-1", wire.Metadata[secure.MetadataKeyEncryptionKeyID])
+	assert.Equal(t, "sig-1", wire.Metadata[secure.MetadataKeySignatureKeyID])
+
+	require.NoError(t, sub.Process(context.Background(), "a", wire))
+	assert.Equal(t, "secret", string(got.Payload))
+	assert.Equal(t, map[string]any{"site": "a"}, got.Metadata)
+
+	tampered := wire
+	tampered.Payload = append([]byte{}, wire.Payload...)
+	tampered.Payload[len(tampered.Payload)-1] ^= 1
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", tampered), types.ErrInvalidPayload)
+
+	unsigned := types.Message{Payload: wire.Payload, Metadata: map[string]any{}}
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", unsigned), types.ErrInvalidPayload)
+}
+
+func TestDecrypt_RejectsTamperedDataKey(t *testing.T) {
+	repo := keyRepo{"mem://kek": {KeyID: "kek-1", Key: random(t, 16)}}
+	keys := secure.NewKeys(repo, "mem://kek", nil)
+
+	var wire types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return nil
+	}), secure.PublishEncrypt(secure.EncryptOptions{Keys: keys}))
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), secure.SubscriberDecrypt(secure.EncryptOptions{Keys: keys}))
+
+	require.NoError(t, pub.Publish(context.Background(), "a", types.Message{Payload: []byte("secret")}))
+	require.NoError(t, sub.Process(context.Background(), "a", wire))
+
+	wire.Metadata[secure.MetadataKeyEncryptionKeyID] = "kek-2"
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", wire), types.ErrInvalidPayload)
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", types.Message{Payload: []byte("plain")}), types.ErrInvalidPayload)
+}
+
+func TestVerify_HMAC(t *testing.T) {
+	keys := secure.NewKeys(keyRepo{"mem://hmac": {KeyID: "h1", Key: random(t, 32)}}, "mem://hmac", nil)
+	opts := secure.SignOptions{Keys: keys, Algorithm: secure.SignatureHMACSHA256}
+
+	var wire types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return nil
+	}), secure.PublishSign(opts))
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), secure.SubscriberVerify(opts))
+
+	require.NoError(t, pub.Publish(context.Background(), "a", types.Message{Payload: []byte("hello")}))
+	require.NoError(t, sub.Process(context.Background(), "a", wire))
+
+	wire.Payload = []byte("hellO")
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", wire), types.ErrInvalidPayload)
+}
+
+func TestVerify_RejectsAlgorithmDowngrade(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	subscriberRepo := keyRepo{"mem://sign": {KeyID: "sig-1", Key: public}}
+
+	// The attacker knows the public key and forges a HMAC keyed with it, claiming `hmac-sha256`.
+	var forged types.Message
+
+	attacker := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		forged = payload
+		return nil
+	}), secure.PublishSign(secure.SignOptions{
+		Keys: secure.NewKeys(keyRepo{"mem://sign": {KeyID: "sig-1", Key: public}}, "mem://sign", nil), Algorithm: secure.SignatureHMACSHA256,
+	}))
+
+	require.NoError(t, attacker.Publish(context.Background(), "a", types.Message{Payload: []byte("open the door")}))
+	assert.Equal(t, secure.SignatureHMACSHA256, forged.Metadata[secure.MetadataKeySignatureAlgorithm])
+
+	var processed int
+
+	next := types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		processed++
+		return nil
+	})
+
+	for name, opts := range map[string]secure.SignOptions{
+		"configured algorithm": {Keys: secure.NewKeys(subscriberRepo, "mem://sign", nil), Algorithm: secure.SignatureEd25519},
+		"key bound algorithm": {Keys: secure.NewKeys(keyRepo{
+			"mem://sign": {KeyID: "sig-1", Key: public, Algorithm: secure.SignatureEd25519},
+		}, "mem://sign", nil)},
+		"no algorithm": {Keys: secure.NewKeys(subscriberRepo, "mem://sign", nil)},
+	} {
+		sub := types.ChainSubscriber(next, secure.SubscriberVerify(opts))
+		assert.ErrorIs(t, sub.Process(context.Background(), "a", forged), types.ErrInvalidPayload, name)
+	}
+
+	assert.Zero(t, processed)
+
+	// A key bound algorithm also applies when signing.
+	signer := secure.NewKeys(keyRepo{"mem://sign": {KeyID: "sig-1", Key: private, Algorithm: secure.SignatureEd25519}}, "mem://sign", nil)
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), secure.PublishSign(secure.SignOptions{Keys: signer, Algorithm: secure.SignatureHMACSHA256}))
+
+	assert.ErrorIs(t, pub.Publish(context.Background(), "a", types.Message{}), types.ErrInvalidPayload)
+}
+
+func TestVerify_CoversTopicAndMetadata(t *testing.T) {
+	keys := secure.NewKeys(keyRepo{"mem://hmac": {KeyID: "h1", Key: random(t, 32)}}, "mem://hmac", nil)
+	opts := secure.SignOptions{Keys: keys, Algorithm: secure.SignatureHMACSHA256}
+
+	var wire types.Message
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		wire = payload
+		return nil
+	}), secure.PublishSign(opts))
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), secure.SubscriberVerify(opts))
+
+	msg := types.Message{Payload: []byte("on"), Metadata: map[string]any{
+		string(types.MessageMetadataKeysReplyTo):       "replies/1",
+		string(types.MessageMetadataKeysCorrelationID): "c-1",
+	}}
+
+	require.NoError(t, pub.Publish(context.Background(), "devices/1/cmd", msg))
+	require.NoError(t, sub.Process(context.Background(), "devices/1/cmd", wire))
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/2/cmd", wire), types.ErrInvalidPayload)
+
+	redirected := wire.WithMetadata(map[string]any{string(types.MessageMetadataKeysReplyTo): "attacker/inbox"})
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/1/cmd", redirected), types.ErrInvalidPayload)
+
+	stripped := wire.WithMetadata(map[string]any{})
+	delete(stripped.Metadata, string(types.MessageMetadataKeysCorrelationID))
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/1/cmd", stripped), types.ErrInvalidPayload)
+
+	// Metadata not covered by the signature may change in transit.
+	require.NoError(t, sub.Process(context.Background(), "devices/1/cmd", wire.WithMetadata(map[string]any{"hop": 2})))
+}
+
+func TestKeys_RefreshesRotatedKeys(t *testing.T) {
+	repo := keyRepo{"mem://kek": {KeyID: "kek-1", Key: random(t, 32)}}
+
+	keys := secure.NewKeys(repo, "mem://kek", nil)
+
+	key, err := keys.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "kek-1", key.KeyID)
+
+	repo["mem://kek"] = types.KeyCredentials{KeyID: "kek-2", Key: random(t, 32)}
+
+	key, err = keys.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "kek-1", key.KeyID, "cached until invalidated")
+
+	keys.Invalidate("mem://kek")
+
+	key, err = keys.ByID("kek-2")
+	require.NoError(t, err)
+	assert.Equal(t, "kek-2", key.KeyID)
+
+	repo["mem://kek"] = types.KeyCredentials{KeyID: "kek-3", Key: random(t, 32)}
+	keys.WithTTL(time.Millisecond).Invalidate()
+
+	_, err = keys.Active()
+	require.NoError(t, err)
+
+	repo["mem://kek"] = types.KeyCredentials{KeyID: "kek-4", Key: random(t, 32)}
+	time.Sleep(5 * time.Millisecond)
+
+	key, err = keys.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "kek-4", key.KeyID, "expired keys are resolved again")
+}
@@ -0,0 +1,272 @@
+package secure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeySignature is the metadata key holding the base64 encoded payload signature.
+	MetadataKeySignature = "signature"
+	// MetadataKeySignatureAlgorithm is the metadata key holding the signature algorithm.
+	MetadataKeySignatureAlgorithm = "signature-alg"
+	// MetadataKeySignatureKeyID is the metadata key holding the id of the signing key.
+	MetadataKeySignatureKeyID = "signature-key-id"
+)
+
+const (
+	// SignatureEd25519 signs with a Ed25519 private key. The key is either the 32 byte seed or the 64 byte
+	// private key. When verifying, a 32 byte key is the public key.
+	SignatureEd25519 = "ed25519"
+	// SignatureHMACSHA256 signs with a HMAC-SHA256 shared secret.
+	SignatureHMACSHA256 = "hmac-sha256"
+)
+
+// DefaultSignedMetadata are the metadata keys covered by the signature when `SignOptions.Metadata` is not set.
+var DefaultSignedMetadata = []string{
+	string(types.MessageMetadataKeysCorrelationID),
+	string(types.MessageMetadataKeysReplyTo),
+}
+
+// SignOptions configures the signing middlewares.
+type SignOptions struct {
+	// Keys resolves the signing (and verification) keys.
+	Keys *Keys
+	// Algorithm is the signature algorithm. When publishing, it defaults to `SignatureEd25519`. When verifying
+	// it is required, unless the key is bound to an algorithm (`types.KeyCredentials.Algorithm`), since the
+	// algorithm in the message metadata is never trusted on its own.
+	//
+	// If both are set, they must be the same.
+	Algorithm string
+	// Metadata are the metadata keys covered by the signature. If `nil`, `DefaultSignedMetadata` is used.
+	Metadata []string
+	// ExcludeTopic excludes the topic from the signature, e.g. when the topic is rewritten between the
+	// publisher and the subscriber.
+	ExcludeTopic bool
+	// Optional, when `true`, lets unsigned messages pass through `SubscriberVerify`. Otherwise they are
+	// rejected with `types.ErrInvalidPayload`.
+	Optional bool
+}
+
+// PublishSign creates a `PublisherMiddleware` that signs the message and sets the signature, algorithm and
+// key id in the metadata (see the `MetadataKeySignature*` constants).
+//
+// The signature covers the algorithm, key id, topic (unless `SignOptions.ExcludeTopic`), the values of the
+// `SignOptions.Metadata` keys and the payload. Other metadata is *not* signed and may be altered in transit.
+//
+// NOTE: When combined with `PublishEncrypt`, sign after encrypting (i.e. place `PublishSign` after `PublishEncrypt`
+// in the chain) so the cipher text is verified before it is decrypted.
+func PublishSign(opts SignOptions) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			key, err := opts.Keys.Active()
+			if err != nil {
+				return err
+			}
+
+			alg, err := opts.algorithm(key, SignatureEd25519)
+			if err != nil {
+				return err
+			}
+
+			sig, err := sign(alg, key.Key, opts.signedData(alg, key.KeyID, topic, payload))
+			if err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			meta[MetadataKeySignature] = base64.StdEncoding.EncodeToString(sig)
+			meta[MetadataKeySignatureAlgorithm] = alg
+			meta[MetadataKeySignatureKeyID] = key.KeyID
+
+			payload.Metadata = meta
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberVerify creates a `SubscriberMiddleware` that verifies the signature set by `PublishSign` and removes
+// the signature metadata. The _topic_ and `SignOptions.Metadata` must be the same as when published.
+//
+// Tampered messages, unknown keys or a algorithm other than the configured one (see `SignOptions.Algorithm`)
+// are rejected with `types.ErrInvalidPayload`.
+func SubscriberVerify(opts SignOptions) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			encoded, _ := payload.Metadata[MetadataKeySignature].(string)
+
+			if encoded == "" {
+				if opts.Optional {
+					return next.Process(ctx, topic, payload)
+				}
+
+				return fmt.Errorf("%w: missing signature", types.ErrInvalidPayload)
+			}
+
+			sig, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("%w: invalid signature: %w", types.ErrInvalidPayload, err)
+			}
+
+			keyID, _ := payload.Metadata[MetadataKeySignatureKeyID].(string)
+
+			key, err := opts.Keys.ByID(keyID)
+			if err != nil {
+				return err
+			}
+
+			alg, err := opts.algorithm(key, "")
+			if err != nil {
+				return err
+			}
+
+			// The algorithm is bound to the configuration, the metadata may only confirm it.
+			if got, _ := payload.Metadata[MetadataKeySignatureAlgorithm].(string); got != alg {
+				return fmt.Errorf("%w: unexpected signature algorithm %q", types.ErrInvalidPayload, got)
+			}
+
+			if err := verify(alg, key.Key, opts.signedData(alg, keyID, topic, payload), sig); err != nil {
+				return err
+			}
+
+			meta := maps.Clone(payload.Metadata)
+			delete(meta, MetadataKeySignature)
+			delete(meta, MetadataKeySignatureAlgorithm)
+			delete(meta, MetadataKeySignatureKeyID)
+
+			payload.Metadata = meta
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+// algorithm returns the algorithm to use with _key_ or _def_ if neither the key nor the options bind one.
+func (o SignOptions) algorithm(key *types.KeyCredentials, def string) (string, error) {
+	switch {
+	case key.Algorithm != "" && o.Algorithm != "" && key.Algorithm != o.Algorithm:
+		return "", fmt.Errorf(
+			"%w: key %q is bound to %q, not %q", types.ErrInvalidPayload, key.KeyID, key.Algorithm, o.Algorithm,
+		)
+	case key.Algorithm != "":
+		return key.Algorithm, nil
+	case o.Algorithm != "":
+		return o.Algorithm, nil
+	case def != "":
+		return def, nil
+	default:
+		return "", fmt.Errorf("%w: no signature algorithm configured for key %q", types.ErrInvalidPayload, key.KeyID)
+	}
+}
+
+// signedData returns the canonical, length prefixed, representation of the signed parts of the message.
+func (o SignOptions) signedData(alg, keyID, topic string, payload types.Message) []byte {
+	keys := o.Metadata
+	if keys == nil {
+		keys = DefaultSignedMetadata
+	}
+
+	if o.ExcludeTopic {
+		topic = ""
+	}
+
+	var b []byte
+
+	field := func(present bool, value []byte) {
+		if !present {
+			b = append(b, 0)
+			return
+		}
+
+		b = append(b, 1)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(value)))
+		b = append(b, value...)
+	}
+
+	field(true, []byte("gobridge-signature-v1"))
+	field(true, []byte(alg))
+	field(true, []byte(keyID))
+	field(true, []byte(topic))
+
+	for _, k := range keys {
+		v, ok := payload.Metadata[k]
+
+		s, isString := v.(string)
+		if !isString && ok {
+			s = fmt.Sprint(v)
+		}
+
+		field(true, []byte(k))
+		field(ok, []byte(s))
+	}
+
+	field(true, payload.Payload)
+
+	return b
+}
+
+func sign(alg string, key, data []byte) ([]byte, error) {
+	switch alg {
+	case SignatureEd25519:
+		switch len(key) {
+		case ed25519.SeedSize:
+			return ed25519.Sign(ed25519.NewKeyFromSeed(key), data), nil
+		case ed25519.PrivateKeySize:
+			return ed25519.Sign(ed25519.PrivateKey(key), data), nil
+		default:
+			return nil, fmt.Errorf("invalid ed25519 private key size %d", len(key))
+		}
+	case SignatureHMACSHA256:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+
+		return mac.Sum(nil), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported signature algorithm %q", types.ErrInvalidPayload, alg)
+	}
+}
+
+func verify(alg string, key, data, sig []byte) error {
+	switch alg {
+	case SignatureEd25519:
+		var public ed25519.PublicKey
+
+		switch len(key) {
+		case ed25519.PublicKeySize:
+			public = ed25519.PublicKey(key)
+		case ed25519.PrivateKeySize:
+			public = ed25519.PrivateKey(key).Public().(ed25519.PublicKey)
+		default:
+			return fmt.Errorf("%w: invalid ed25519 public key size %d", types.ErrInvalidPayload, len(key))
+		}
+
+		if !ed25519.Verify(public, data, sig) {
+			return fmt.Errorf("%w: signature mismatch", types.ErrInvalidPayload)
+		}
+
+		return nil
+	case SignatureHMACSHA256:
+		expected, _ := sign(alg, key, data)
+
+		if !hmac.Equal(expected, sig) {
+			return fmt.Errorf("%w: signature mismatch", types.ErrInvalidPayload)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported signature algorithm %q", types.ErrInvalidPayload, alg)
+	}
+}
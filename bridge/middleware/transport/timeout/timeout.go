@@ -0,0 +1,167 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// MetadataKeyDeadline is the metadata key carrying the absolute deadline of the message. It is either
+// a `time.Time` or a RFC 3339 (nano) formatted `string`.
+const MetadataKeyDeadline = "deadline"
+
+// Options configures the timeout middlewares.
+type Options struct {
+	// Timeout is the default timeout for each `Publish` or `Process` call. If zero, only the
+	// message _TTL_, the `MetadataKeyDeadline` or the incoming context deadline is used.
+	Timeout time.Duration
+	// UseTTL indicates that the message expiry (`CreatedAt` + `TTL`) shall bound the call.
+	UseTTL bool
+	// Propagate indicates that the effective deadline shall be written to the `MetadataKeyDeadline`
+	// of the message passed on to next in chain so the far side of the bridge can honour it.
+	Propagate bool
+}
+
+// PublishTimeout creates a `PublisherMiddleware` that bounds each `Publish` with a context deadline.
+//
+// The deadline is the earliest of the configured `Options.Timeout`, the message expiry (if `Options.UseTTL`),
+// the `MetadataKeyDeadline` metadata and the deadline of the incoming context. If the deadline is exceeded,
+// `types.ErrPublishTimeout` is returned.
+func PublishTimeout(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			ctx, payload, cancel := opts.bound(ctx, payload)
+			defer cancel()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return timeoutError(ctx.Err())
+			}
+
+			err := next.Publish(ctx, topic, payload)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) {
+				return timeoutError(err)
+			}
+
+			return err
+		})
+	}
+}
+
+// SubscriberTimeout creates a `SubscriberMiddleware` that bounds each `Process` with a context deadline
+// in the same manner as `PublishTimeout`.
+//
+// When the message deadline (`MetadataKeyDeadline` or, if `Options.UseTTL`, the message expiry) has already
+// passed when the message is received, `types.ErrMessageExpired` is returned without calling the `Subscriber`
+// since retrying it can never succeed.
+//
+// When the deadline is exceeded while processing, `types.ErrPublishTimeout` is returned. It is recoverable,
+// i.e. an outer retrying middleware may retry it, but the `Connection` do only re-deliver a message when a
+// `types.BackoffError` is returned (see `types.Subscriber`).
+func SubscriberTimeout(opts Options) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if deadline := opts.messageDeadline(payload); !deadline.IsZero() && !time.Now().Before(deadline) {
+				return fmt.Errorf("%w: deadline %s has passed", types.ErrMessageExpired, deadline.UTC().Format(time.RFC3339Nano))
+			}
+
+			ctx, payload, cancel := opts.bound(ctx, payload)
+			defer cancel()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return timeoutError(ctx.Err())
+			}
+
+			err := next.Process(ctx, topic, payload)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) {
+				return timeoutError(err)
+			}
+
+			return err
+		})
+	}
+}
+
+// Deadline returns the deadline stored in the `MetadataKeyDeadline` of _payload_, if any.
+func Deadline(payload types.Message) (time.Time, bool) {
+	if payload.Metadata == nil {
+		return time.Time{}, false
+	}
+
+	switch v := payload.Metadata[MetadataKeyDeadline].(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// bound derives a context with the effective deadline and, if configured, propagates it into a copy of _payload_.
+func (o Options) bound(ctx context.Context, payload types.Message) (context.Context, types.Message, context.CancelFunc) {
+	var deadline time.Time
+
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (deadline.IsZero() || t.Before(deadline)) {
+			deadline = t
+		}
+	}
+
+	if o.Timeout > 0 {
+		earliest(time.Now().Add(o.Timeout))
+	}
+
+	earliest(o.messageDeadline(payload))
+
+	if t, ok := ctx.Deadline(); ok {
+		earliest(t)
+	}
+
+	if deadline.IsZero() {
+		return ctx, payload, func() {}
+	}
+
+	if o.Propagate {
+		meta := maps.Clone(payload.Metadata)
+		if meta == nil {
+			meta = map[string]any{}
+		}
+
+		meta[MetadataKeyDeadline] = deadline.UTC().Format(time.RFC3339Nano)
+		payload.Metadata = meta
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+
+	return ctx, payload, cancel
+}
+
+// messageDeadline returns the earliest of the `MetadataKeyDeadline` and, if `Options.UseTTL`, the expiry of
+// _payload_. A zero time is returned if the message has no deadline.
+func (o Options) messageDeadline(payload types.Message) time.Time {
+	var deadline time.Time
+
+	if o.UseTTL && payload.TTL > 0 {
+		deadline = payload.CreatedAt.Add(payload.TTL)
+	}
+
+	if t, ok := Deadline(payload); ok && (deadline.IsZero() || t.Before(deadline)) {
+		deadline = t
+	}
+
+	return deadline
+}
+
+// timeoutError maps _err_ onto `types.ErrPublishTimeout` while keeping the original error in the chain.
+func timeoutError(err error) error {
+	return fmt.Errorf("%w: %w", types.ErrPublishTimeout, err)
+}
@@ -0,0 +1,91 @@
+package timeout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/timeout"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishTimeout_MapsDeadlineToPublishTimeout(t *testing.T) {
+	pub := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		timeout.PublishTimeout(timeout.Options{Timeout: 10 * time.Millisecond}),
+	)
+
+	err := pub.Publish(context.Background(), "a", types.Message{})
+	assert.ErrorIs(t, err, types.ErrPublishTimeout)
+	assert.True(t, types.IsRecoverable(err))
+}
+
+func TestPublishTimeout_PropagatesEarliestDeadline(t *testing.T) {
+	var (
+		got         types.Message
+		ctxDeadline time.Time
+	)
+
+	pub := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			got = payload
+			ctxDeadline, _ = ctx.Deadline()
+			return nil
+		}),
+		timeout.PublishTimeout(timeout.Options{Timeout: time.Hour, UseTTL: true, Propagate: true}),
+	)
+
+	created := time.Now()
+	require.NoError(t, pub.Publish(context.Background(), "a", types.Message{CreatedAt: created, TTL: time.Minute}))
+
+	deadline, ok := timeout.Deadline(got)
+	require.True(t, ok)
+	assert.WithinDuration(t, created.Add(time.Minute), deadline, time.Millisecond)
+	assert.WithinDuration(t, deadline, ctxDeadline, time.Millisecond)
+}
+
+func TestSubscriberTimeout_HonoursMetadataDeadline(t *testing.T) {
+	var calls int
+
+	sub := types.ChainSubscriber(
+		types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			calls++
+			return nil
+		}),
+		timeout.SubscriberTimeout(timeout.Options{UseTTL: true}),
+	)
+
+	// A deadline that passed before the message was received can never be met, hence it is not retried.
+	past := types.Message{Metadata: map[string]any{timeout.MetadataKeyDeadline: time.Now().Add(-time.Second)}}
+	err := sub.Process(context.Background(), "a", past)
+	assert.ErrorIs(t, err, types.ErrMessageExpired)
+	assert.False(t, types.IsRecoverable(err))
+
+	expired := types.Message{CreatedAt: time.Now().Add(-time.Minute), TTL: time.Second}
+	assert.ErrorIs(t, sub.Process(context.Background(), "a", expired), types.ErrMessageExpired)
+	assert.Zero(t, calls)
+
+	future := types.Message{Metadata: map[string]any{
+		timeout.MetadataKeyDeadline: time.Now().Add(time.Minute).Format(time.RFC3339Nano),
+	}}
+	assert.NoError(t, sub.Process(context.Background(), "a", future))
+}
+
+func TestSubscriberTimeout_ExceededWhileProcessing(t *testing.T) {
+	sub := types.ChainSubscriber(
+		types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		timeout.SubscriberTimeout(timeout.Options{Timeout: 10 * time.Millisecond}),
+	)
+
+	err := sub.Process(context.Background(), "a", types.Message{})
+	assert.ErrorIs(t, err, types.ErrPublishTimeout)
+	assert.True(t, types.IsRecoverable(err))
+}
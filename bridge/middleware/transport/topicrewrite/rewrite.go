@@ -0,0 +1,102 @@
+package topicrewrite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Rule rewrites topics matching the _From_ pattern into the _To_ `topic.Template`.
+type Rule struct {
+	// From is the topic pattern, e.g. `devices/+id/telemetry`.
+	From string `json:"from"`
+	// To is the topic template, e.g. `sb://telemetry?device={id}`.
+	To string `json:"to"`
+}
+
+// ParseRule parses a rule on the form `from -> to`, e.g. `devices/+id/telemetry -> sb://telemetry?device={id}`.
+func ParseRule(rule string) (Rule, error) {
+	from, to, ok := strings.Cut(rule, "->")
+	if !ok {
+		return Rule{}, fmt.Errorf("%w: rewrite rule %q must be on the form 'from -> to'", types.ErrInvalidTopicName, rule)
+	}
+
+	return Rule{From: strings.TrimSpace(from), To: strings.TrimSpace(to)}, nil
+}
+
+// Options configures the rewrite middleware.
+type Options struct {
+	// Rules are the rewrite rules. When multiple rules match, the first one wins.
+	Rules []Rule
+	// Syntax is the wildcard syntax of the rule patterns.
+	Syntax topic.Syntax
+	// Validate optionally validates the rewritten topic against the target rules, e.g. `topic.ValidatorFor`.
+	Validate topic.Validator
+	// Strict rejects topics not matching any rule with `types.ErrInvalidTopicName`. Otherwise, those are
+	// passed through unchanged (but still validated).
+	Strict bool
+}
+
+// Rewrite creates a `PublisherMiddleware` that rewrites both the _topic_ argument and the `Message.Topic`
+// according to the first matching `Rule`.
+//
+// The rules are compiled once and an error, wrapping `types.ErrInvalidTopicName` or
+// `types.ErrSubscriptionInvalidTopicName`, is returned if any rule is invalid.
+func Rewrite(opts Options) (types.PublisherMiddleware, error) {
+	trie := topic.NewTrie[*topic.Template](opts.Syntax)
+
+	for _, rule := range opts.Rules {
+		p, err := topic.Parse(rule.From, opts.Syntax)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := topic.ParseTemplate(rule.To, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := trie.Insert(rule.From, t); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			rewritten, err := rewrite(trie, opts, topic)
+			if err != nil {
+				return err
+			}
+
+			payload.Topic = rewritten
+
+			return next.Publish(ctx, rewritten, payload)
+		})
+	}, nil
+}
+
+func rewrite(trie *topic.Trie[*topic.Template], opts Options, source string) (string, error) {
+	target := source
+
+	if matches := trie.Match(source); len(matches) > 0 {
+		rendered, err := matches[0].Value.Render(source, matches[0].Captures)
+		if err != nil {
+			return "", err
+		}
+
+		target = rendered
+	} else if opts.Strict {
+		return "", fmt.Errorf("%w: %q does not match any rewrite rule", types.ErrInvalidTopicName, source)
+	}
+
+	if opts.Validate != nil {
+		if err := opts.Validate(target); err != nil {
+			return "", err
+		}
+	}
+
+	return target, nil
+}
@@ -0,0 +1,45 @@
+package topicrewrite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/topicrewrite"
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewrite_RewritesAndValidates(t *testing.T) {
+	telemetry, err := topicrewrite.ParseRule("devices/+id/telemetry -> sb://telemetry?device={id}")
+	require.NoError(t, err)
+
+	mw, err := topicrewrite.Rewrite(topicrewrite.Options{
+		Rules:    []topicrewrite.Rule{telemetry, {From: "devices/+id/#", To: "devices/{id}/bad+"}},
+		Validate: topic.ValidatorFor(types.TransportTypeMQTT),
+		Strict:   true,
+	})
+	require.NoError(t, err)
+
+	var got []string
+
+	pub := types.ChainPublisher(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = append(got, topic, payload.Topic)
+		return nil
+	}), mw)
+
+	require.NoError(t, pub.Publish(context.Background(), "devices/7/telemetry", types.Message{Topic: "devices/7/telemetry"}))
+	assert.Equal(t, []string{"sb://telemetry?device=7", "sb://telemetry?device=7"}, got)
+
+	assert.ErrorIs(t, pub.Publish(context.Background(), "devices/7/state", types.Message{}), types.ErrInvalidTopicName)
+	assert.ErrorIs(t, pub.Publish(context.Background(), "unknown", types.Message{}), types.ErrInvalidTopicName)
+}
+
+func TestRewrite_InvalidRules(t *testing.T) {
+	_, err := topicrewrite.ParseRule("devices/+id")
+	assert.ErrorIs(t, err, types.ErrInvalidTopicName)
+
+	_, err = topicrewrite.Rewrite(topicrewrite.Options{Rules: []topicrewrite.Rule{{From: "a/+id", To: "b/{dev}"}}})
+	assert.ErrorIs(t, err, types.ErrInvalidTopicName)
+}
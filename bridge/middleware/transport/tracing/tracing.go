@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/tracing"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the `trace.Tracer` used when `Options.Tracer` is not set.
+const InstrumentationName = "github.com/mariotoffia/gobridge/bridge/middleware/transport/tracing"
+
+// Options configures the tracing middlewares.
+type Options struct {
+	// Tracer is used to create the spans. If `nil`, the tracer named `InstrumentationName` is fetched from
+	// the global `otel.GetTracerProvider`.
+	Tracer trace.Tracer
+	// Propagator injects / extracts the span context into / from the `Metadata`. If `nil`, the W3C
+	// `propagation.TraceContext` is used.
+	Propagator propagation.TextMapPropagator
+	// ConnectionID is recorded as the _messaging.connection_ span attribute.
+	ConnectionID string
+	// TransportType is recorded as the _messaging.system_ span attribute.
+	TransportType types.TransportType
+}
+
+// PublishTracing creates a `PublisherMiddleware` that starts a producer span around `Publisher.Publish` and
+// injects the W3C _traceparent_ and _tracestate_ of the span into the `Metadata` of the published message.
+//
+// The span is a child of the span in the incoming _ctx_ (if any).
+func PublishTracing(opts Options) types.PublisherMiddleware {
+	opts = opts.defaults()
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			ctx, span := opts.Tracer.Start(
+				ctx, "publish "+topic,
+				trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(opts.attributes(topic, payload)...),
+			)
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			opts.Propagator.Inject(ctx, tracing.MetadataCarrier(meta))
+			payload.Metadata = meta
+
+			err := next.Publish(ctx, topic, payload)
+			end(span, err)
+
+			return err
+		})
+	}
+}
+
+// SubscriberTracing creates a `SubscriberMiddleware` that extracts the W3C _traceparent_ and _tracestate_
+// from the `Metadata` of the received message and starts a consumer span, as a child of it, around
+// `Subscriber.Process`.
+//
+// The span is available to the `Subscriber` through `trace.SpanFromContext`, hence a `PublishTracing`
+// further down the bridge continues the same trace.
+func SubscriberTracing(opts Options) types.SubscriberMiddleware {
+	opts = opts.defaults()
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			ctx = opts.Propagator.Extract(ctx, tracing.MetadataCarrier(payload.Metadata))
+
+			ctx, span := opts.Tracer.Start(
+				ctx, "process "+topic,
+				trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(opts.attributes(topic, payload)...),
+			)
+
+			err := next.Process(ctx, topic, payload)
+			end(span, err)
+
+			return err
+		})
+	}
+}
+
+func (o Options) defaults() Options {
+	if o.Tracer == nil {
+		o.Tracer = otel.GetTracerProvider().Tracer(InstrumentationName)
+	}
+
+	if o.Propagator == nil {
+		o.Propagator = propagation.TraceContext{}
+	}
+
+	return o
+}
+
+func (o Options) attributes(topic string, payload types.Message) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.destination.name", topic),
+		attribute.Int("messaging.message.body.size", len(payload.Payload)),
+	}
+
+	if o.ConnectionID != "" {
+		attrs = append(attrs, attribute.String("messaging.connection", o.ConnectionID))
+	}
+
+	if o.TransportType != "" {
+		attrs = append(attrs, attribute.String("messaging.system", string(o.TransportType)))
+	}
+
+	return attrs
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,98 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	mw "github.com/mariotoffia/gobridge/bridge/middleware/transport/tracing"
+	"github.com/mariotoffia/gobridge/bridge/tracing"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type exportedSpan struct {
+	Name        string
+	SpanKind    int
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+	}
+	Status struct {
+		Code string
+	}
+}
+
+func TestTracing_TraceFlowsAcrossTheBridge(t *testing.T) {
+	var buf bytes.Buffer
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(&buf))
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	opts := mw.Options{Tracer: provider.Tracer("test"), TransportType: types.TransportTypeMQTT}
+
+	var (
+		wire     types.Message
+		received trace.SpanContext
+	)
+
+	// device consumer side
+	consumer := types.ChainSubscriber(
+		types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			received = trace.SpanContextFromContext(ctx)
+			return errors.New("boom")
+		}),
+		mw.SubscriberTracing(opts),
+	)
+
+	// lambda producer side
+	producer := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			wire = payload
+			return nil
+		}),
+		mw.PublishTracing(opts),
+	)
+
+	require.NoError(t, producer.Publish(context.Background(), "devices/1/cmd", types.Message{}))
+
+	tp, ok := wire.Metadata[tracing.MetadataKeyTraceParent].(string)
+	require.True(t, ok)
+
+	sent, err := tracing.ParseTraceParent(tp)
+	require.NoError(t, err)
+
+	require.Error(t, consumer.Process(context.Background(), "devices/1/cmd", wire))
+	assert.Equal(t, sent.TraceID(), received.TraceID())
+	assert.NotEqual(t, sent.SpanID(), received.SpanID())
+
+	var spans []exportedSpan
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var span exportedSpan
+		require.NoError(t, dec.Decode(&span))
+		spans = append(spans, span)
+	}
+
+	require.Len(t, spans, 2)
+	assert.Equal(t, "publish devices/1/cmd", spans[0].Name)
+	assert.Equal(t, int(trace.SpanKindProducer), spans[0].SpanKind)
+	assert.Equal(t, int(trace.SpanKindConsumer), spans[1].SpanKind)
+	assert.Equal(t, sent.SpanID().String(), spans[1].Parent.SpanID)
+	assert.Equal(t, sent.TraceID().String(), spans[1].SpanContext.TraceID)
+	assert.Equal(t, "Error", spans[1].Status.Code)
+}
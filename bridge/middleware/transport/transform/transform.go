@@ -0,0 +1,225 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/expr"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options declares how to reshape the message. All expressions are evaluated over the variables
+// from `expr.MessageVars` (_topic_, _payload_, _metadata_ and _qos_) of the incoming message.
+type Options struct {
+	// Fields maps output payload paths (dot separated, e.g. `data.temperature`) to `expr.Expression`
+	// sources, e.g. `payload.temp`.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Templates maps output payload paths to `expr.Template` sources, e.g. `device-${payload.id}`.
+	Templates map[string]string `json:"templates,omitempty"`
+	// Metadata maps metadata keys to `expr.Expression` sources. A `null` result removes the key.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// KeepPayload keeps the fields of the original JSON payload object and sets the _Fields_ and _Templates_
+	// on top of it. Otherwise, a new payload object containing only the _Fields_ and _Templates_ is created.
+	KeepPayload bool `json:"keep_payload,omitempty"`
+}
+
+// Transformer is a compiled set of `Options`.
+type Transformer struct {
+	opts      Options
+	fields    map[string]*expr.Expression
+	templates map[string]*expr.Template
+	metadata  map[string]*expr.Expression
+	// paths are all output payload paths sorted.
+	paths []string
+}
+
+// Compile compiles the expressions in _opts_ so they are validated once at configuration load.
+func Compile(opts Options) (*Transformer, error) {
+	t := &Transformer{
+		opts:      opts,
+		fields:    map[string]*expr.Expression{},
+		templates: map[string]*expr.Template{},
+		metadata:  map[string]*expr.Expression{},
+	}
+
+	for path, src := range opts.Fields {
+		e, err := expr.Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", path, err)
+		}
+
+		t.fields[path] = e
+	}
+
+	for path, src := range opts.Templates {
+		if _, ok := t.fields[path]; ok {
+			return nil, fmt.Errorf("path %q is both a field and a template", path)
+		}
+
+		tmpl, err := expr.CompileTemplate(src)
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", path, err)
+		}
+
+		t.templates[path] = tmpl
+	}
+
+	for key, src := range opts.Metadata {
+		e, err := expr.Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("metadata %q: %w", key, err)
+		}
+
+		t.metadata[key] = e
+	}
+
+	t.paths = append(slices.Collect(maps.Keys(t.fields)), slices.Collect(maps.Keys(t.templates))...)
+	slices.Sort(t.paths)
+
+	for i := 1; i < len(t.paths); i++ {
+		if strings.HasPrefix(t.paths[i], t.paths[i-1]+".") {
+			return nil, fmt.Errorf("path %q conflicts with path %q", t.paths[i], t.paths[i-1])
+		}
+	}
+
+	return t, nil
+}
+
+// Apply transforms the _payload_ received/published on _topic_ and returns the transformed copy.
+//
+// Any failure is returned as a `types.ErrInvalidPayload` wrapped error.
+func (t *Transformer) Apply(topic string, payload types.Message) (types.Message, error) {
+	vars, err := expr.MessageVars(topic, payload)
+	if err != nil {
+		return payload, err
+	}
+
+	if len(t.paths) > 0 {
+		out := map[string]any{}
+
+		if t.opts.KeepPayload {
+			if obj, ok := vars["payload"].(map[string]any); ok {
+				out = maps.Clone(obj)
+			}
+		}
+
+		for _, path := range t.paths {
+			var value any
+
+			if e, ok := t.fields[path]; ok {
+				value, err = e.Eval(vars)
+			} else {
+				value, err = t.templates[path].Render(vars)
+			}
+
+			if err != nil {
+				return payload, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+			}
+
+			if err := set(out, path, value); err != nil {
+				return payload, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+			}
+		}
+
+		data, err := json.Marshal(out)
+		if err != nil {
+			return payload, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+		}
+
+		payload.Payload = data
+	}
+
+	if len(t.metadata) > 0 {
+		meta := maps.Clone(payload.Metadata)
+		if meta == nil {
+			meta = map[string]any{}
+		}
+
+		for key, e := range t.metadata {
+			value, err := e.Eval(vars)
+			if err != nil {
+				return payload, fmt.Errorf("%w: %w", types.ErrInvalidPayload, err)
+			}
+
+			if value == nil {
+				delete(meta, key)
+				continue
+			}
+
+			meta[key] = value
+		}
+
+		payload.Metadata = meta
+	}
+
+	return payload, nil
+}
+
+// PublishTransform creates a `PublisherMiddleware` that transforms the message before it is published.
+func PublishTransform(opts Options) (types.PublisherMiddleware, error) {
+	t, err := Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			payload, err := t.Apply(topic, payload)
+			if err != nil {
+				return err
+			}
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}, nil
+}
+
+// SubscriberTransform creates a `SubscriberMiddleware` that transforms the message before it is processed.
+func SubscriberTransform(opts Options) (types.SubscriberMiddleware, error) {
+	t, err := Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			payload, err := t.Apply(topic, payload)
+			if err != nil {
+				return err
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}, nil
+}
+
+// set sets _value_ at the dot separated _path_ in _obj_, creating intermediate objects as needed.
+func set(obj map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := obj[key]
+		if !ok || child == nil {
+			next := map[string]any{}
+			obj[key] = next
+			obj = next
+
+			continue
+		}
+
+		next, ok := child.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %q: %q is not an object", path, key)
+		}
+
+		obj = next
+	}
+
+	obj[keys[len(keys)-1]] = value
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package transform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/transform"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriberTransform_ReshapesIntoCanonicalEvent(t *testing.T) {
+	mw, err := transform.SubscriberTransform(transform.Options{
+		Fields: map[string]string{
+			"type":             `"telemetry"`,
+			"data.temperature": `payload.t / 10`,
+			"data.unit":        `coalesce(payload.unit, "C")`,
+		},
+		Templates: map[string]string{"source": "device/${payload.id}"},
+		Metadata:  map[string]string{"device-id": "payload.id", "secret": "null"},
+	})
+	require.NoError(t, err)
+
+	var got types.Message
+
+	sub := types.ChainSubscriber(types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		got = payload
+		return nil
+	}), mw)
+
+	require.NoError(t, sub.Process(context.Background(), "devices/7", types.Message{
+		Payload:  []byte(`{"id": "dev-7", "t": 215}`),
+		Metadata: map[string]any{"secret": "x"},
+	}))
+
+	assert.JSONEq(t, `{"type":"telemetry","source":"device/dev-7","data":{"temperature":21.5,"unit":"C"}}`, string(got.Payload))
+	assert.Equal(t, map[string]any{"device-id": "dev-7"}, got.Metadata)
+
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/7", types.Message{Payload: []byte(`{`)}), types.ErrInvalidPayload)
+	assert.ErrorIs(t, sub.Process(context.Background(), "devices/7", types.Message{Payload: []byte(`{"t": "x"}`)}), types.ErrInvalidPayload)
+}
+
+func TestCompile_InvalidOptions(t *testing.T) {
+	_, err := transform.Compile(transform.Options{Fields: map[string]string{"a": "payload."}})
+	assert.Error(t, err)
+
+	_, err = transform.Compile(transform.Options{Fields: map[string]string{"a": "1", "a.b": "2"}})
+	assert.Error(t, err)
+}
+
+func TestTransformer_KeepsLargeIntegers(t *testing.T) {
+	tr, err := transform.Compile(transform.Options{
+		Fields:      map[string]string{"device": "payload.id", "next": "payload.id + 1", "raw": "payload.nested"},
+		KeepPayload: true,
+	})
+	require.NoError(t, err)
+
+	got, err := tr.Apply("a", types.Message{Payload: []byte(`{"id": 9007199254740993, "nested": {"n": 18446744073709551615}}`)})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"id": 9007199254740993, "nested": {"n": 18446744073709551615},
+		"device": 9007199254740993, "next": 9007199254740994, "raw": {"n": 18446744073709551615}
+	}`, string(got.Payload))
+	// `JSONEq` compares float64 values, hence the exact digits are asserted as well.
+	assert.Contains(t, string(got.Payload), `"device":9007199254740993`)
+	assert.Contains(t, string(got.Payload), `"next":9007199254740994`)
+}
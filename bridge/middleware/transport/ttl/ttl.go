@@ -0,0 +1,142 @@
+package ttl
+
+import (
+	"context"
+	"maps"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyExpiresAt is the metadata key holding the absolute expiry (`time.Time`, UTC) of the message.
+	MetadataKeyExpiresAt = "expires-at"
+	// MetadataKeyNativeExpiry is the metadata key holding the transport-native expiry hint as
+	// returned by `NativeExpiry`. The transport maps it onto its own expiry header.
+	MetadataKeyNativeExpiry = "native-expiry"
+)
+
+// Counters keeps track of the number of expired messages. It is safe for concurrent use.
+type Counters struct {
+	// PublishExpired is the number of messages that expired before being published.
+	PublishExpired atomic.Int64
+	// ReceiveExpired is the number of messages that expired before being processed.
+	ReceiveExpired atomic.Int64
+}
+
+// Options configures the TTL middlewares.
+type Options struct {
+	// Drop indicates that expired messages are silently dropped (`nil` is returned). When `false`,
+	// `types.ErrMessageExpired` is returned, e.g. to let an outer dead-letter middleware forward it.
+	Drop bool
+	// TransportType is the transport the messages are published on. When set, the publisher middleware
+	// adds a `MetadataKeyNativeExpiry` hint.
+	TransportType types.TransportType
+	// Counters is optional and is incremented for each expired message.
+	Counters *Counters
+	// OnExpired is an optional callback that is invoked for each expired message.
+	OnExpired func(ctx context.Context, topic string, payload types.Message)
+}
+
+// PublishTTL creates a `PublisherMiddleware` that rejects messages that have expired (see `types.Message.IsExpired`)
+// and adds the remaining time-to-live as expiry hints in the `Metadata` of the outgoing message.
+//
+// Messages without a _TTL_ are passed through untouched.
+func PublishTTL(opts Options) types.PublisherMiddleware {
+	return func(next types.Publisher) types.Publisher {
+		return types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if payload.TTL == 0 {
+				return next.Publish(ctx, topic, payload)
+			}
+
+			if err := payload.IsExpired(); err != nil {
+				if opts.Counters != nil {
+					opts.Counters.PublishExpired.Add(1)
+				}
+
+				return opts.expired(ctx, topic, payload, err)
+			}
+
+			expiresAt := payload.CreatedAt.Add(payload.TTL)
+
+			meta := maps.Clone(payload.Metadata)
+			if meta == nil {
+				meta = map[string]any{}
+			}
+
+			meta[MetadataKeyExpiresAt] = expiresAt.UTC()
+
+			if opts.TransportType != "" {
+				if hint := NativeExpiry(opts.TransportType, expiresAt); hint != nil {
+					meta[MetadataKeyNativeExpiry] = hint
+				}
+			}
+
+			payload.Metadata = meta
+
+			return next.Publish(ctx, topic, payload)
+		})
+	}
+}
+
+// SubscriberTTL creates a `SubscriberMiddleware` that drops or rejects messages that have expired
+// before they are handed to the `Subscriber`.
+func SubscriberTTL(opts Options) types.SubscriberMiddleware {
+	return func(next types.Subscriber) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			if err := payload.IsExpired(); err != nil {
+				if opts.Counters != nil {
+					opts.Counters.ReceiveExpired.Add(1)
+				}
+
+				return opts.expired(ctx, topic, payload, err)
+			}
+
+			return next.Process(ctx, topic, payload)
+		})
+	}
+}
+
+func (o Options) expired(ctx context.Context, topic string, payload types.Message, err error) error {
+	if o.OnExpired != nil {
+		o.OnExpired(ctx, topic, payload)
+	}
+
+	if o.Drop {
+		return nil
+	}
+
+	return err
+}
+
+// NativeExpiry translates the absolute _expiresAt_ into the transport-native representation:
+//
+// - MQTT: message-expiry-interval in seconds as `uint32` (rounded up, at least one).
+//
+// - AzureServiceBus: `TimeToLive` as `time.Duration`.
+//
+// It returns `nil` when the transport has no per-message expiry, e.g. SQS that only has a queue-level retention
+// period, is unknown or the message has already expired. Such messages rely on the expiry check of
+// `SubscriberTTL` on the receiving side.
+func NativeExpiry(transport types.TransportType, expiresAt time.Time) any {
+	remaining := time.Until(expiresAt)
+	if remaining <= 0 {
+		return nil
+	}
+
+	switch transport {
+	case types.TransportTypeMQTT:
+		secs := math.Ceil(remaining.Seconds())
+		if secs > math.MaxUint32 {
+			secs = math.MaxUint32
+		}
+
+		return uint32(secs)
+	case types.TransportTypeAzureServiceBus:
+		return remaining
+	default:
+		return nil
+	}
+}
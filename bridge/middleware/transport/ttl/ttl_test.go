@@ -0,0 +1,80 @@
+package ttl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/middleware/transport/ttl"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishTTL_ExpiredIsRejectedAndCounted(t *testing.T) {
+	var (
+		counters ttl.Counters
+		called   bool
+	)
+
+	pub := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			called = true
+			return nil
+		}),
+		ttl.PublishTTL(ttl.Options{Counters: &counters}),
+	)
+
+	msg := types.Message{CreatedAt: time.Now().Add(-time.Minute), TTL: time.Second}
+
+	assert.ErrorIs(t, pub.Publish(context.Background(), "a", msg), types.ErrMessageExpired)
+	assert.False(t, called)
+	assert.Equal(t, int64(1), counters.PublishExpired.Load())
+}
+
+func TestPublishTTL_AddsExpiryHints(t *testing.T) {
+	var got types.Message
+
+	pub := types.ChainPublisher(
+		types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			got = payload
+			return nil
+		}),
+		ttl.PublishTTL(ttl.Options{TransportType: types.TransportTypeMQTT}),
+	)
+
+	created := time.Now()
+	require.NoError(t, pub.Publish(context.Background(), "a", types.Message{CreatedAt: created, TTL: 90 * time.Second}))
+
+	assert.Equal(t, created.Add(90*time.Second).UTC(), got.Metadata[ttl.MetadataKeyExpiresAt])
+	assert.Equal(t, uint32(90), got.Metadata[ttl.MetadataKeyNativeExpiry])
+
+	// SQS has no per-message expiry.
+	expiresAt := time.Now().Add(time.Minute)
+	assert.Nil(t, ttl.NativeExpiry(types.TransportTypeSQS, expiresAt))
+	assert.IsType(t, time.Duration(0), ttl.NativeExpiry(types.TransportTypeAzureServiceBus, expiresAt))
+}
+
+func TestSubscriberTTL_DropExpired(t *testing.T) {
+	var (
+		counters ttl.Counters
+		called   bool
+	)
+
+	sub := types.ChainSubscriber(
+		types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			called = true
+			return nil
+		}),
+		ttl.SubscriberTTL(ttl.Options{Drop: true, Counters: &counters}),
+	)
+
+	msg := types.Message{CreatedAt: time.Now().Add(-time.Minute), TTL: time.Second}
+
+	require.NoError(t, sub.Process(context.Background(), "a", msg))
+	assert.False(t, called)
+	assert.Equal(t, int64(1), counters.ReceiveExpired.Load())
+
+	require.NoError(t, sub.Process(context.Background(), "a", types.Message{CreatedAt: time.Now(), TTL: time.Minute}))
+	assert.True(t, called)
+}
@@ -0,0 +1,131 @@
+package reqreply_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/reqreply"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// broker is a in-memory loopback `Publisher` and `SubscriberSource` on exact topics.
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]types.Subscriber
+	// strings converts the metadata values to strings, like MQTT 5 user properties.
+	strings bool
+}
+
+func (b *broker) AddSubscriber(id, topic string, subscriber types.Subscriber, opts ...types.AddSubscriberOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs[topic] = subscriber
+
+	return nil
+}
+
+func (b *broker) RemoveSubscriber(id, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subs, topic)
+
+	return nil
+}
+
+func (b *broker) Publish(ctx context.Context, topic string, payload types.Message) error {
+	b.mu.Lock()
+	sub, ok := b.subs[topic]
+	b.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if b.strings {
+		meta := make(map[string]any, len(payload.Metadata))
+		for k, v := range payload.Metadata {
+			meta[k] = fmt.Sprint(v)
+		}
+
+		payload.Metadata = meta
+	}
+
+	go func() { _ = sub.Process(context.Background(), topic, payload) }()
+
+	return nil
+}
+
+func TestRequester_RoundTrip(t *testing.T) {
+	b := &broker{subs: map[string]types.Subscriber{}}
+
+	require.NoError(t, b.AddSubscriber("device", "devices/1/cmd", reqreply.NewResponder(b,
+		func(ctx context.Context, topic string, request types.Message) (types.Message, error) {
+			if string(request.Payload) == "fail" {
+				return types.Message{}, types.ErrInvalidPayload
+			}
+
+			return types.Message{Payload: append([]byte("pong:"), request.Payload...)}, nil
+		})))
+
+	r, err := reqreply.NewRequester(b, b, reqreply.RequesterOptions{ReplyTopic: "replies/lambda-1"})
+	require.NoError(t, err)
+
+	reply, err := r.Request(context.Background(), "devices/1/cmd", types.Message{Payload: []byte("ping")})
+	require.NoError(t, err)
+	assert.Equal(t, "pong:ping", string(reply.Payload))
+	assert.NotEmpty(t, reply.Metadata[reqreply.MetadataKeyCorrelationID])
+
+	_, err = r.Request(context.Background(), "devices/1/cmd", types.Message{Payload: []byte("fail")})
+	be, ok := types.AsBridgeError(err)
+	require.True(t, ok)
+	assert.Equal(t, 422, be.HttpCode)
+	assert.Contains(t, be.Error(), "invalid payload")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = r.Request(ctx, "devices/2/cmd", types.Message{Payload: []byte("ping")})
+	assert.ErrorIs(t, err, types.ErrPublishTimeout)
+
+	require.NoError(t, r.Close())
+
+	_, err = r.Request(context.Background(), "devices/1/cmd", types.Message{})
+	assert.ErrorIs(t, err, types.ErrServerNotConnected)
+}
+
+func TestResponder_RequiresReplyTo(t *testing.T) {
+	res := reqreply.NewResponder(types.PublisherAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+		return nil
+	}), func(ctx context.Context, topic string, request types.Message) (types.Message, error) {
+		return types.Message{}, nil
+	})
+
+	assert.ErrorIs(t, res.Process(context.Background(), "a", types.Message{}), types.ErrInvalidPayload)
+}
+
+func TestRequester_StringReplyErrorProperties(t *testing.T) {
+	b := &broker{subs: map[string]types.Subscriber{}, strings: true}
+
+	require.NoError(t, b.AddSubscriber("device", "devices/1/cmd", reqreply.NewResponder(b,
+		func(ctx context.Context, topic string, request types.Message) (types.Message, error) {
+			return types.Message{}, types.NewBridgeError("busy", true, 503)
+		})))
+
+	r, err := reqreply.NewRequester(b, b, reqreply.RequesterOptions{ReplyTopic: "replies/lambda-1"})
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	_, err = r.Request(context.Background(), "devices/1/cmd", types.Message{})
+	be, ok := types.AsBridgeError(err)
+	require.True(t, ok)
+	assert.Equal(t, 503, be.HttpCode)
+	assert.True(t, be.IsRecoverable)
+}
@@ -0,0 +1,207 @@
+package reqreply
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+const (
+	// MetadataKeyCorrelationID is the metadata key holding the id correlating a reply with its request.
+	MetadataKeyCorrelationID = string(types.MessageMetadataKeysCorrelationID)
+	// MetadataKeyReplyTo is the metadata key holding the topic the reply shall be published on.
+	MetadataKeyReplyTo = string(types.MessageMetadataKeysReplyTo)
+	// MetadataKeyReplyError is the metadata key holding the error message when the request failed.
+	MetadataKeyReplyError = "reply-error"
+	// MetadataKeyReplyErrorCode is the metadata key holding the `types.BridgeError.HttpCode` of the failure (if any).
+	MetadataKeyReplyErrorCode = "reply-error-code"
+	// MetadataKeyReplyRecoverable is the metadata key indicating if the failure is recoverable.
+	MetadataKeyReplyRecoverable = "reply-recoverable"
+)
+
+// DefaultTimeout is the default time to wait for a reply.
+const DefaultTimeout = 30 * time.Second
+
+// RequesterOptions configures the `Requester`.
+type RequesterOptions struct {
+	// ReplyTopic is the topic where the replies are received. It should be unique per `Requester`
+	// instance, e.g. `replies/<instance-id>`.
+	ReplyTopic string
+	// SubscriberID is the id used when registering on the `types.SubscriberSource`. If empty,
+	// `reqreply-<ReplyTopic>` is used.
+	SubscriberID string
+	// Timeout is the default time to wait for a reply, when the _ctx_ has no deadline. If zero,
+	// `DefaultTimeout` is used.
+	Timeout time.Duration
+}
+
+// Requester does synchronous request/reply over asynchronous transports.
+//
+// The request is published with a generated correlation id and the reply topic in its metadata (see the
+// `MetadataKey*` constants) and the `Requester` awaits the correlated reply on the reply topic.
+type Requester struct {
+	publisher types.Publisher
+	source    types.SubscriberSource
+	opts      RequesterOptions
+	mu        sync.Mutex
+	pending   map[string]chan types.Message
+	closed    bool
+}
+
+// NewRequester creates a new `Requester` that publishes the requests on _publisher_ and registers itself on
+// _source_ to receive the replies on `RequesterOptions.ReplyTopic`.
+func NewRequester(publisher types.Publisher, source types.SubscriberSource, opts RequesterOptions) (*Requester, error) {
+	if opts.ReplyTopic == "" {
+		return nil, fmt.Errorf("%w: reply topic is required", types.ErrInvalidTopicName)
+	}
+
+	if opts.SubscriberID == "" {
+		opts.SubscriberID = "reqreply-" + opts.ReplyTopic
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	r := &Requester{
+		publisher: publisher,
+		source:    source,
+		opts:      opts,
+		pending:   map[string]chan types.Message{},
+	}
+
+	if err := source.AddSubscriber(opts.SubscriberID, opts.ReplyTopic, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Request publishes _payload_ on _topic_ and waits for the reply.
+//
+// If no reply is received before _ctx_ is done, or `RequesterOptions.Timeout` when _ctx_ has no deadline,
+// a `types.ErrPublishTimeout` wrapped error is returned. When the responder failed to handle the request,
+// its error is returned as a `types.BridgeError`.
+func (r *Requester) Request(ctx context.Context, topic string, payload types.Message) (types.Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+
+	id, err := newCorrelationID()
+	if err != nil {
+		return types.Message{}, err
+	}
+
+	reply := make(chan types.Message, 1)
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return types.Message{}, types.ErrServerNotConnected
+	}
+
+	r.pending[id] = reply
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	meta := maps.Clone(payload.Metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+
+	meta[MetadataKeyCorrelationID] = id
+	meta[MetadataKeyReplyTo] = r.opts.ReplyTopic
+
+	payload.Metadata = meta
+
+	if err := r.publisher.Publish(ctx, topic, payload); err != nil {
+		return types.Message{}, err
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return types.Message{}, types.ErrServerNotConnected
+		}
+
+		return msg, replyError(msg)
+	case <-ctx.Done():
+		return types.Message{}, fmt.Errorf("%w: no reply for %q: %w", types.ErrPublishTimeout, id, ctx.Err())
+	}
+}
+
+// Process receives the replies and hands them to the awaiting `Request`. Replies without a pending request
+// (e.g. late replies) are dropped.
+func (r *Requester) Process(_ context.Context, _ string, payload types.Message) error {
+	id := payload.CorrelationID()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reply, ok := r.pending[id]; ok {
+		delete(r.pending, id)
+		reply <- payload
+	}
+
+	return nil
+}
+
+// Close removes the `Requester` from the `types.SubscriberSource` and fails all pending requests with
+// `types.ErrServerNotConnected`.
+func (r *Requester) Close() error {
+	r.mu.Lock()
+
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+
+	r.closed = true
+
+	for id, reply := range r.pending {
+		delete(r.pending, id)
+		close(reply)
+	}
+
+	r.mu.Unlock()
+
+	return r.source.RemoveSubscriber(r.opts.SubscriberID, r.opts.ReplyTopic)
+}
+
+// replyError returns the error carried by a failed reply, if any.
+func replyError(msg types.Message) error {
+	message, ok := msg.Metadata[MetadataKeyReplyError].(string)
+	if !ok {
+		return nil
+	}
+
+	// The code and recoverable flag may have been converted by the transport, e.g. to strings.
+	return types.NewBridgeError(
+		message,
+		msg.GetMetadataBool(MetadataKeyReplyRecoverable, false),
+		msg.GetMetadataInt(MetadataKeyReplyErrorCode, 0),
+	)
+}
+
+func newCorrelationID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,62 @@
+package reqreply
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Handler handles a request and returns the reply.
+type Handler func(ctx context.Context, topic string, request types.Message) (types.Message, error)
+
+// Responder is a `types.Subscriber` serving requests sent by a `Requester`.
+//
+// It invokes the `Handler` and publishes the reply, with the correlation id of the request, on the reply
+// topic of the request. When the `Handler` fails, a reply with the error details (see the `MetadataKeyReply*`
+// constants) is published so the `Requester` fails fast instead of timing out.
+type Responder struct {
+	publisher types.Publisher
+	handler   Handler
+}
+
+// NewResponder creates a new `Responder` that invokes _handler_ and publishes the replies on _publisher_.
+func NewResponder(publisher types.Publisher, handler Handler) *Responder {
+	return &Responder{publisher: publisher, handler: handler}
+}
+
+// Process handles the request and publishes the reply.
+//
+// Requests without a reply topic are rejected with `types.ErrInvalidPayload`. Errors publishing the reply are
+// returned as is.
+func (r *Responder) Process(ctx context.Context, topic string, payload types.Message) error {
+	replyTo := payload.ReplyTo()
+	if replyTo == "" {
+		return fmt.Errorf("%w: request without %q metadata", types.ErrInvalidPayload, MetadataKeyReplyTo)
+	}
+
+	reply, err := r.handler(ctx, topic, payload)
+	if err != nil {
+		reply = types.Message{Metadata: map[string]any{
+			MetadataKeyReplyError:       err.Error(),
+			MetadataKeyReplyRecoverable: types.IsRecoverable(err),
+		}}
+
+		if be, ok := types.AsBridgeError(err); ok && be.IsHttpCodeSet() {
+			reply.Metadata[MetadataKeyReplyErrorCode] = be.HttpCode
+		}
+	}
+
+	meta := maps.Clone(reply.Metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+
+	meta[MetadataKeyCorrelationID] = payload.Metadata[MetadataKeyCorrelationID]
+
+	reply.Topic = replyTo
+	reply.Metadata = meta
+
+	return r.publisher.Publish(ctx, replyTo, reply)
+}
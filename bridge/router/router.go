@@ -0,0 +1,150 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Options configures the `TopicRouter`.
+type Options struct {
+	// OnError is invoked for each `Subscriber` that fails (or panics) when processing a message.
+	OnError func(ctx context.Context, id, topic string, payload types.Message, err error)
+}
+
+type route struct {
+	id         string
+	subscriber types.Subscriber
+}
+
+// TopicRouter is a `types.SubscriberRouter` that matches the concrete `Message.Topic` of received messages against
+// the registered topic patterns and dispatches the message to all matching `Subscriber` instances.
+//
+// The patterns are indexed in a `topic.Trie` using a single `topic.Syntax` (_MQTT_, _AMQP_ or glob).
+//
+// Each `Subscriber` is isolated from the others, i.e. an error or panic in one does not prevent the
+// message from being dispatched to the rest.
+type TopicRouter struct {
+	// mu serializes the registrations, the trie itself is safe for concurrent use.
+	mu   sync.Mutex
+	trie *topic.Trie[*route]
+	opts Options
+}
+
+type capturesKey struct{}
+
+// NewTopicRouter creates a new, empty, `TopicRouter` for patterns in _syntax_.
+func NewTopicRouter(syntax topic.Syntax, opts ...Options) *TopicRouter {
+	r := &TopicRouter{trie: topic.NewTrie[*route](syntax)}
+
+	if len(opts) > 0 {
+		r.opts = opts[0]
+	}
+
+	return r
+}
+
+// CapturesFromContext returns the wildcard captures of the pattern that routed the message to the
+// current `Subscriber`.
+func CapturesFromContext(ctx context.Context) (topic.Captures, bool) {
+	c, ok := ctx.Value(capturesKey{}).(topic.Captures)
+	return c, ok
+}
+
+// AddSubscriber registers the _subscriber_ with _id_ on the _topic_ pattern.
+//
+// Errors that may be returned:
+//
+// - ErrSubscriptionAlreadyExists: if the subscriber with the same _id_ is already registered for the specified _topic_.
+//
+// - ErrSubscriptionInvalidTopicName: if the specified _topic_ is not a valid pattern.
+func (r *TopicRouter) AddSubscriber(id, topic string, subscriber types.Subscriber, _ ...types.AddSubscriberOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rt := range r.trie.Values(topic) {
+		if rt.id == id {
+			return types.ErrSubscriptionAlreadyExists
+		}
+	}
+
+	_, err := r.trie.Insert(topic, &route{id: id, subscriber: subscriber})
+
+	return err
+}
+
+// RemoveSubscriber removes the subscriber with _id_ on the _topic_ pattern.
+//
+// Errors that may be returned:
+//
+// - ErrNotFound: if the subscriber with the specified _id_ on _topic_ is not found.
+func (r *TopicRouter) RemoveSubscriber(id, topic string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.trie.Remove(topic, func(rt *route) bool { return rt.id == id }) == 0 {
+		return types.ErrNotFound
+	}
+
+	return nil
+}
+
+// Process dispatches the message to all `Subscriber` instances with a pattern matching the concrete
+// `Message.Topic` of _payload_, in the order they were registered. When `Message.Topic` is empty, the _topic_
+// argument is matched instead. The wildcard captures are available through `CapturesFromContext`.
+//
+// Each failing `Subscriber` is reported to `Options.OnError`. It returns `nil` when no pattern matches or all
+// subscribers succeeded. A backoff (`types.BackoffError`) is only returned when every failing subscriber asked
+// for one, all of them joined. Otherwise the non backoff errors are returned joined, so the `SubscriberSource`
+// drops the message instead of re-delivering it.
+//
+// NOTE: A re-delivery is dispatched to all matching subscribers again, including those that succeeded.
+func (r *TopicRouter) Process(ctx context.Context, topic string, payload types.Message) error {
+	concrete := payload.Topic
+	if concrete == "" {
+		concrete = topic
+	}
+
+	var backoffs, errs []error
+
+	for _, m := range r.trie.Match(concrete) {
+		rctx := context.WithValue(ctx, capturesKey{}, m.Captures)
+
+		err := process(rctx, m.Value.subscriber, topic, payload)
+		if err == nil {
+			continue
+		}
+
+		if r.opts.OnError != nil {
+			r.opts.OnError(rctx, m.Value.id, concrete, payload, err)
+		}
+
+		var backoff *types.BackoffError
+		if errors.As(err, &backoff) {
+			backoffs = append(backoffs, err)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return errors.Join(backoffs...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// process calls the _subscriber_ and converts a panic into an error.
+func process(ctx context.Context, subscriber types.Subscriber, topic string, payload types.Message) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("subscriber panic on topic %q: %v", topic, rec)
+		}
+	}()
+
+	return subscriber.Process(ctx, topic, payload)
+}
@@ -0,0 +1,90 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/router"
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ types.SubscriberRouter = (*router.TopicRouter)(nil)
+
+func TestTopicRouter_DispatchesToAllMatchingWithIsolation(t *testing.T) {
+	var (
+		calls  []string
+		failed []string
+	)
+
+	r := router.NewTopicRouter(topic.SyntaxMQTT, router.Options{
+		OnError: func(ctx context.Context, id, topic string, payload types.Message, err error) {
+			failed = append(failed, id)
+		},
+	})
+
+	record := func(id string) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error {
+			captures, _ := router.CapturesFromContext(ctx)
+			calls = append(calls, id+":"+captures.Named["id"])
+			return nil
+		})
+	}
+
+	require.NoError(t, r.AddSubscriber("a", "devices/+id/telemetry", record("a")))
+	require.NoError(t, r.AddSubscriber("panic", "devices/#", types.SubscriberAdapter(
+		func(ctx context.Context, topic string, payload types.Message) error { panic("boom") },
+	)))
+	require.NoError(t, r.AddSubscriber("b", "devices/+id/#", record("b")))
+	require.NoError(t, r.AddSubscriber("c", "other/+", record("c")))
+
+	assert.ErrorIs(t, r.AddSubscriber("a", "devices/+id/telemetry", record("a")), types.ErrSubscriptionAlreadyExists)
+	assert.ErrorIs(t, r.AddSubscriber("x", "devices/#/x", record("x")), types.ErrSubscriptionInvalidTopicName)
+
+	err := r.Process(context.Background(), "devices/7/telemetry", types.Message{Topic: "devices/7/telemetry"})
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, []string{"a:7", "b:7"}, calls)
+	assert.Equal(t, []string{"panic"}, failed)
+
+	require.NoError(t, r.RemoveSubscriber("panic", "devices/#"))
+	assert.ErrorIs(t, r.RemoveSubscriber("panic", "devices/#"), types.ErrNotFound)
+	assert.NoError(t, r.Process(context.Background(), "devices/7/telemetry", types.Message{}))
+}
+
+func TestTopicRouter_BackoffOnlyWhenAllFailuresAskedForIt(t *testing.T) {
+	var failed []string
+
+	r := router.NewTopicRouter(topic.SyntaxMQTT, router.Options{
+		OnError: func(ctx context.Context, id, topic string, payload types.Message, err error) {
+			failed = append(failed, id+":"+topic)
+		},
+	})
+
+	returning := func(err error) types.Subscriber {
+		return types.SubscriberAdapter(func(ctx context.Context, topic string, payload types.Message) error { return err })
+	}
+
+	require.NoError(t, r.AddSubscriber("ok", "devices/#", returning(nil)))
+	require.NoError(t, r.AddSubscriber("throttled", "devices/+/telemetry", returning(types.NewBackoffError("throttled", 1))))
+	require.NoError(t, r.AddSubscriber("busy", "devices/+/telemetry", returning(types.ErrBackoff)))
+	require.NoError(t, r.AddSubscriber("invalid", "devices/+/cmd", returning(types.ErrInvalidPayload)))
+	require.NoError(t, r.AddSubscriber("slow", "devices/+/cmd", returning(types.ErrBackoff)))
+
+	// The concrete `Message.Topic` is matched, not the subscription topic.
+	err := r.Process(context.Background(), "devices/#", types.Message{Topic: "devices/1/telemetry"})
+	assert.ErrorIs(t, err, types.ErrBackoff)
+	assert.ErrorContains(t, err, "throttled")
+	assert.Equal(t, []string{"throttled:devices/1/telemetry", "busy:devices/1/telemetry"}, failed)
+
+	failed = nil
+
+	err = r.Process(context.Background(), "devices/#", types.Message{Topic: "devices/1/cmd"})
+	assert.ErrorIs(t, err, types.ErrInvalidPayload)
+	assert.NotErrorIs(t, err, types.ErrBackoff)
+	assert.Equal(t, []string{"invalid:devices/1/cmd", "slow:devices/1/cmd"}, failed)
+
+	// Falls back on the topic argument when `Message.Topic` is not set.
+	assert.NoError(t, r.Process(context.Background(), "devices/1/other", types.Message{}))
+}
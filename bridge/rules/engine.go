@@ -0,0 +1,175 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// ConnectionLookup looks up connections by id, e.g. a `types.ConnectionRegistry`.
+type ConnectionLookup interface {
+	GetConnection(id string) (types.Connection, error)
+}
+
+// Options configures the `Engine`.
+type Options struct {
+	// PublisherMiddlewares are applied on the target `types.Publisher` of each rule.
+	PublisherMiddlewares []types.PublisherMiddleware
+	// SubscriberMiddlewares are applied on the `types.Subscriber` registered on the source of each rule.
+	SubscriberMiddlewares []types.SubscriberMiddleware
+	// OnUnmatched is invoked when a message received by the rule _ruleID_ has a concrete _topic_
+	// (`types.Message.Topic`) that does not match the _SourceTopic_ pattern of the rule, e.g. when the source
+	// delivers a broader subscription. Such messages are dropped (acknowledged) and this hook allows to log or
+	// count them.
+	OnUnmatched func(ctx context.Context, ruleID, topic string, payload types.Message)
+}
+
+// Engine bridges messages between connections according to a set of `Rule`.
+//
+// For each rule, it registers a `types.Subscriber` on the source `types.SubscriberSource` that rewrites the topic,
+// maps the QoS and metadata, and publishes the message on the target `types.Publisher`. The source is subscribed
+// with the _SourceTopic_ without capture names (see `topic.Pattern.Subscription`) since a broker do not
+// understand them.
+//
+// The error from the target publish is returned to the source, hence a recoverable error may result in
+// a re-delivery when the source `types.Connection` supports it.
+type Engine struct {
+	lookup ConnectionLookup
+	opts   Options
+	mu     sync.Mutex
+	rules  map[string]*active
+}
+
+type active struct {
+	rule   *compiled
+	source types.SubscriberSource
+}
+
+// NewEngine creates a new `Engine` that resolves the rule connections using _lookup_.
+func NewEngine(lookup ConnectionLookup, opts ...Options) *Engine {
+	e := &Engine{lookup: lookup, rules: map[string]*active{}}
+
+	if len(opts) > 0 {
+		e.opts = opts[0]
+	}
+
+	return e
+}
+
+// Add validates the _rule_, resolves its connections and subscribes on the source.
+//
+// If a rule with the same id already exists, `types.ErrSubscriptionAlreadyExists` is returned. If the source
+// or target connection is not found, `types.ErrNotFound` is returned, and if they do not implement
+// `types.SubscriberSource` respective `types.Publisher`, `types.ErrProtocolMismatch` is returned.
+func (e *Engine) Add(rule Rule) error {
+	c, err := compile(rule)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.rules[rule.ID]; ok {
+		return fmt.Errorf("bridge rule %q: %w", rule.ID, types.ErrSubscriptionAlreadyExists)
+	}
+
+	source, err := resolve[types.SubscriberSource](e.lookup, rule.Source, "SubscriberSource")
+	if err != nil {
+		return fmt.Errorf("bridge rule %q: %w", rule.ID, err)
+	}
+
+	target, err := resolve[types.Publisher](e.lookup, rule.Target, "Publisher")
+	if err != nil {
+		return fmt.Errorf("bridge rule %q: %w", rule.ID, err)
+	}
+
+	target = types.ChainPublisher(target, e.opts.PublisherMiddlewares...)
+
+	subscriber := types.ChainSubscriber(types.SubscriberAdapter(
+		func(ctx context.Context, topic string, payload types.Message) error {
+			// The _topic_ may be the subscription, hence the concrete topic of the message is matched.
+			if payload.Topic != "" {
+				topic = payload.Topic
+			}
+
+			targetTopic, msg, ok, err := c.transform(topic, payload)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				if e.opts.OnUnmatched != nil {
+					e.opts.OnUnmatched(ctx, rule.ID, topic, payload)
+				}
+
+				return nil
+			}
+
+			return target.Publish(ctx, targetTopic, msg)
+		},
+	), e.opts.SubscriberMiddlewares...)
+
+	if err := source.AddSubscriber(rule.ID, c.matcher.Subscription(), subscriber); err != nil {
+		return fmt.Errorf("bridge rule %q: %w", rule.ID, err)
+	}
+
+	e.rules[rule.ID] = &active{rule: c, source: source}
+
+	return nil
+}
+
+// Remove removes the rule with _id_ and unsubscribes it from the source. If not found, `types.ErrNotFound`
+// is returned.
+func (e *Engine) Remove(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	a, ok := e.rules[id]
+	if !ok {
+		return types.ErrNotFound
+	}
+
+	if err := a.source.RemoveSubscriber(id, a.rule.matcher.Subscription()); err != nil {
+		return fmt.Errorf("bridge rule %q: %w", id, err)
+	}
+
+	delete(e.rules, id)
+
+	return nil
+}
+
+// Rules returns the currently active rules ordered by their id.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]Rule, 0, len(e.rules))
+	for _, a := range e.rules {
+		rules = append(rules, a.rule.rule)
+	}
+
+	slices.SortFunc(rules, func(a, b Rule) int { return strings.Compare(a.ID, b.ID) })
+
+	return rules
+}
+
+func resolve[T any](lookup ConnectionLookup, id, kind string) (T, error) {
+	var zero T
+
+	conn, err := lookup.GetConnection(id)
+	if err != nil {
+		return zero, fmt.Errorf("connection %q: %w", id, err)
+	}
+
+	v, ok := conn.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: connection %q is not a %s", types.ErrProtocolMismatch, id, kind)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,190 @@
+package rules_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/router"
+	"github.com/mariotoffia/gobridge/bridge/rules"
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnection is a in-memory `types.Connection` that is both `types.SubscriberSource` and `types.Publisher`.
+type fakeConnection struct {
+	*router.TopicRouter
+	id        string
+	published []types.Message
+}
+
+func (c *fakeConnection) Close() error                                         { return nil }
+func (c *fakeConnection) GetID() string                                        { return c.id }
+func (c *fakeConnection) GetTransportType() types.TransportType                { return types.TransportTypeMQTT }
+func (c *fakeConnection) Start(context.Context, types.ConnectionConfig) error  { return nil }
+func (c *fakeConnection) Capabilities(...string) map[string]types.Capabilities { return nil }
+func (c *fakeConnection) Publish(_ context.Context, _ string, m types.Message) error {
+	c.published = append(c.published, m)
+	return nil
+}
+
+type lookup map[string]types.Connection
+
+func (l lookup) GetConnection(id string) (types.Connection, error) {
+	if c, ok := l[id]; ok {
+		return c, nil
+	}
+
+	return nil, types.ErrNotFound
+}
+
+func TestEngine_ForwardsWithTopicQosAndMetadataMapping(t *testing.T) {
+	mqtt := &fakeConnection{TopicRouter: router.NewTopicRouter(topic.SyntaxMQTT), id: "mqtt"}
+	sb := &fakeConnection{TopicRouter: router.NewTopicRouter(topic.SyntaxAMQP), id: "sb"}
+
+	rs, err := rules.LoadRules(strings.NewReader(`[{
+		"id": "telemetry",
+		"source": "mqtt",
+		"source_topic": "devices/+id/telemetry",
+		"syntax": "mqtt",
+		"target": "sb",
+		"target_topic": "telemetry.{id}",
+		"qos": {"levels": {"1": 2}},
+		"metadata": {"exclude": ["secret"], "set": {"bridge": "b1"}}
+	}]`))
+	require.NoError(t, err)
+
+	engine := rules.NewEngine(lookup{"mqtt": mqtt, "sb": sb})
+	require.NoError(t, engine.Add(rs[0]))
+	assert.ErrorIs(t, engine.Add(rs[0]), types.ErrSubscriptionAlreadyExists)
+
+	msg := types.Message{
+		Topic:    "devices/42/telemetry",
+		Payload:  []byte(`{"t":1}`),
+		Qos:      &types.QosLevel{Level: 1},
+		Metadata: map[string]any{"secret": "x", "region": "eu"},
+	}
+	require.NoError(t, mqtt.Process(context.Background(), msg.Topic, msg))
+
+	require.Len(t, sb.published, 1)
+	got := sb.published[0]
+	assert.Equal(t, "telemetry.42", got.Topic)
+	assert.Equal(t, 2, got.Qos.Level)
+	assert.Equal(t, map[string]any{"region": "eu", "bridge": "b1"}, got.Metadata)
+	assert.Equal(t, "x", msg.Metadata["secret"], "source message must not be mutated")
+
+	require.NoError(t, engine.Remove("telemetry"))
+	require.NoError(t, mqtt.Process(context.Background(), msg.Topic, msg))
+	assert.Len(t, sb.published, 1)
+}
+
+func TestEngine_InvalidRules(t *testing.T) {
+	engine := rules.NewEngine(lookup{"src": &fakeConnection{TopicRouter: router.NewTopicRouter(topic.SyntaxMQTT), id: "src"}})
+
+	err := engine.Add(rules.Rule{ID: "a", Source: "src", SourceTopic: "a/+x", Target: "src", TargetTopic: "b/{y}"})
+	assert.ErrorIs(t, err, types.ErrInvalidTopicName)
+
+	err = engine.Add(rules.Rule{ID: "a", Source: "src", SourceTopic: "a/#", Target: "missing", TargetTopic: "b"})
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	assert.ErrorIs(t, engine.Remove("a"), types.ErrNotFound)
+}
+
+func TestEngine_UnmatchedDefaultQosAndOrder(t *testing.T) {
+	src := &fakeConnection{TopicRouter: router.NewTopicRouter(topic.SyntaxMQTT), id: "src"}
+	dst := &fakeConnection{TopicRouter: router.NewTopicRouter(topic.SyntaxMQTT), id: "dst"}
+
+	var unmatched []string
+
+	engine := rules.NewEngine(lookup{"src": src, "dst": dst}, rules.Options{
+		OnUnmatched: func(ctx context.Context, ruleID, topic string, payload types.Message) {
+			unmatched = append(unmatched, ruleID+":"+topic)
+		},
+	})
+
+	def := &types.QosLevel{Level: 1, Custom: map[string]any{"k": "v"}}
+
+	require.NoError(t, engine.Add(rules.Rule{
+		ID: "b", Source: "src", SourceTopic: "a/+", Target: "dst", TargetTopic: "b", QoS: &rules.QosMapping{Default: def},
+	}))
+	require.NoError(t, engine.Add(rules.Rule{ID: "a", Source: "src", SourceTopic: "x/#", Target: "dst", TargetTopic: "y"}))
+
+	ids := []string{}
+	for _, r := range engine.Rules() {
+		ids = append(ids, r.ID)
+	}
+
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	require.NoError(t, src.Process(context.Background(), "a/1", types.Message{Topic: "a/1"}))
+	require.Len(t, dst.published, 1)
+
+	dst.published[0].Qos.Custom["k"] = "mutated"
+	dst.published[0].Qos.Level = 2
+	assert.Equal(t, &types.QosLevel{Level: 1, Custom: map[string]any{"k": "v"}}, def)
+
+	// The rule matches the concrete `Message.Topic`, not the topic it is handed.
+	require.NoError(t, src.Process(context.Background(), "other", types.Message{Topic: "a/1"}))
+	assert.Len(t, dst.published, 2)
+	assert.Empty(t, unmatched)
+
+	// A source that delivers a broader subscription.
+	broad := &recordingSource{fakeConnection: fakeConnection{id: "broad"}}
+	engine = rules.NewEngine(lookup{"broad": broad, "dst": dst}, rules.Options{
+		OnUnmatched: func(ctx context.Context, ruleID, topic string, payload types.Message) {
+			unmatched = append(unmatched, ruleID+":"+topic)
+		},
+	})
+
+	require.NoError(t, engine.Add(rules.Rule{ID: "c", Source: "broad", SourceTopic: "a/+", Target: "dst", TargetTopic: "b"}))
+	require.NoError(t, broad.subscribers[0].Process(context.Background(), "#", types.Message{Topic: "x/1"}))
+	assert.Len(t, dst.published, 2)
+	assert.Equal(t, []string{"c:x/1"}, unmatched)
+}
+
+// recordingSource is a `types.SubscriberSource` that records the subscribed topics.
+type recordingSource struct {
+	fakeConnection
+	topics      []string
+	subscribers []types.Subscriber
+}
+
+func (s *recordingSource) AddSubscriber(_, topic string, sub types.Subscriber, _ ...types.AddSubscriberOptions) error {
+	s.topics = append(s.topics, topic)
+	s.subscribers = append(s.subscribers, sub)
+
+	return nil
+}
+
+func (s *recordingSource) RemoveSubscriber(_, topic string) error {
+	s.topics = slices.DeleteFunc(s.topics, func(t string) bool { return t == topic })
+	return nil
+}
+
+func TestEngine_SubscribesWithoutCaptureNames(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		syntax  topic.Syntax
+		want    string
+	}{
+		{"devices/+id/telemetry", topic.SyntaxMQTT, "devices/+/telemetry"},
+		{"devices/+id/#rest", topic.SyntaxMQTT, "devices/+/#"},
+		{"devices.*id.#rest", topic.SyntaxAMQP, "devices.*.#"},
+		{"devices/dev-*/**", topic.SyntaxGlob, "devices/dev-*/**"},
+	} {
+		src := &recordingSource{fakeConnection: fakeConnection{id: "src"}}
+		dst := &fakeConnection{id: "dst"}
+		engine := rules.NewEngine(lookup{"src": src, "dst": dst})
+
+		require.NoError(t, engine.Add(rules.Rule{
+			ID: "r", Source: "src", SourceTopic: tc.pattern, Syntax: tc.syntax, Target: "dst", TargetTopic: "out",
+		}))
+		assert.Equal(t, []string{tc.want}, src.topics, tc.pattern)
+
+		require.NoError(t, engine.Remove("r"))
+		assert.Empty(t, src.topics, tc.pattern)
+	}
+}
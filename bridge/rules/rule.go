@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Rule declares that messages received on the _SourceTopic_ of the _Source_ connection shall be forwarded to the
+// _TargetTopic_ of the _Target_ connection.
+type Rule struct {
+	// ID uniquely identifies the rule. It is used as the subscriber id on the source connection.
+	ID string `json:"id"`
+	// Source is the `types.Connection` id that must implement `types.SubscriberSource`.
+	Source string `json:"source"`
+	// SourceTopic is the topic pattern to subscribe to on the source connection.
+	SourceTopic string `json:"source_topic"`
+	// Syntax is the wildcard syntax of the _SourceTopic_ (defaults to _mqtt_).
+	Syntax topic.Syntax `json:"syntax,omitempty"`
+	// Target is the `types.Connection` id that must implement `types.Publisher`.
+	Target string `json:"target"`
+	// TargetTopic is a `topic.Template` rendered with the wildcard captures of the _SourceTopic_,
+	// e.g. `devices/+id/telemetry` -> `telemetry.{id}`.
+	TargetTopic string `json:"target_topic"`
+	// QoS optionally maps the QoS level of the source message onto the target.
+	QoS *QosMapping `json:"qos,omitempty"`
+	// Metadata optionally controls which metadata is carried over to the target.
+	Metadata *MetadataMapping `json:"metadata,omitempty"`
+}
+
+// QosMapping maps the source QoS onto the target QoS.
+type QosMapping struct {
+	// Levels maps a source QoS level onto a target QoS level.
+	Levels map[int]int `json:"levels,omitempty"`
+	// Default is the QoS used when the source message has no QoS or its level is not in _Levels_.
+	// If `nil`, the source QoS is kept as is.
+	Default *types.QosLevel `json:"default,omitempty"`
+}
+
+// MetadataMapping controls the metadata carried over from the source to the target message.
+type MetadataMapping struct {
+	// Include lists the keys to carry over. If empty, all keys are carried over.
+	Include []string `json:"include,omitempty"`
+	// Exclude lists the keys that are never carried over.
+	Exclude []string `json:"exclude,omitempty"`
+	// Set are static metadata added to the target message.
+	Set map[string]any `json:"set,omitempty"`
+}
+
+// LoadRules reads a JSON array of `Rule` from _r_.
+func LoadRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to decode bridge rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// compiled is a validated `Rule` with parsed pattern and template.
+type compiled struct {
+	rule Rule
+	// matcher is the source pattern, its trie is compiled once by `compile` and reused for each message.
+	matcher  *topic.Pattern
+	template *topic.Template
+}
+
+func compile(rule Rule) (*compiled, error) {
+	if rule.ID == "" || rule.Source == "" || rule.Target == "" {
+		return nil, fmt.Errorf("bridge rule %q must have id, source and target", rule.ID)
+	}
+
+	pattern, err := topic.Parse(rule.SourceTopic, rule.Syntax)
+	if err != nil {
+		return nil, fmt.Errorf("bridge rule %q: %w", rule.ID, err)
+	}
+
+	if rule.TargetTopic == "" {
+		return nil, fmt.Errorf("bridge rule %q: %w: empty target topic", rule.ID, types.ErrInvalidTopicName)
+	}
+
+	template, err := topic.ParseTemplate(rule.TargetTopic, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("bridge rule %q: %w", rule.ID, err)
+	}
+
+	return &compiled{rule: rule, matcher: pattern, template: template}, nil
+}
+
+// transform creates the message to publish on the target from the _payload_ received on the concrete source
+// _topic_.
+//
+// It returns `false` if _topic_ does not match the source pattern.
+func (c *compiled) transform(topic string, payload types.Message) (string, types.Message, bool, error) {
+	captures, ok := c.matcher.Match(topic)
+	if !ok {
+		return "", payload, false, nil
+	}
+
+	target, err := c.template.Render(topic, captures)
+	if err != nil {
+		return "", payload, false, err
+	}
+
+	payload.Topic = target
+	payload.Qos = c.rule.QoS.apply(payload.Qos)
+	payload.Metadata = c.rule.Metadata.apply(payload.Metadata)
+
+	return target, payload, true, nil
+}
+
+func (q *QosMapping) apply(qos *types.QosLevel) *types.QosLevel {
+	if q == nil {
+		return qos
+	}
+
+	if qos != nil {
+		if level, ok := q.Levels[qos.Level]; ok {
+			return &types.QosLevel{Level: level, Custom: qos.Custom}
+		}
+	}
+
+	if q.Default != nil {
+		// Clone, so the target message (or a middleware) cannot mutate the rule.
+		return &types.QosLevel{Level: q.Default.Level, Custom: maps.Clone(q.Default.Custom)}
+	}
+
+	return qos
+}
+
+func (m *MetadataMapping) apply(metadata map[string]any) map[string]any {
+	if m == nil {
+		return maps.Clone(metadata)
+	}
+
+	out := make(map[string]any, len(metadata)+len(m.Set))
+
+	for k, v := range metadata {
+		if len(m.Include) > 0 && !slices.Contains(m.Include, k) {
+			continue
+		}
+
+		if slices.Contains(m.Exclude, k) {
+			continue
+		}
+
+		out[k] = v
+	}
+
+	for k, v := range m.Set {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+)
+
+// Registry holds schemas registered per topic pattern.
+type Registry struct {
+	trie *topic.Trie[*Schema]
+}
+
+// NewRegistry creates a new, empty, `Registry` for topic patterns in _syntax_.
+func NewRegistry(syntax topic.Syntax) *Registry {
+	return &Registry{trie: topic.NewTrie[*Schema](syntax)}
+}
+
+// Register registers the _schema_ for the topic _pattern_.
+func (r *Registry) Register(pattern string, schema *Schema) error {
+	_, err := r.trie.Insert(pattern, schema)
+	return err
+}
+
+// RegisterFile loads the schema from the local file at _path_ and registers it for the topic _pattern_.
+func (r *Registry) RegisterFile(pattern, path string) error {
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Register(pattern, s); err != nil {
+		return fmt.Errorf("schema %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Lookup returns all schemas whose pattern matches the concrete _topic_, in registration order.
+func (r *Registry) Lookup(topic string) []*Schema {
+	matches := r.trie.Match(topic)
+
+	schemas := make([]*Schema, 0, len(matches))
+	for _, m := range matches {
+		schemas = append(schemas, m.Value)
+	}
+
+	return schemas
+}
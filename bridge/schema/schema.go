@@ -0,0 +1,658 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Schema is a compiled _JSON Schema_.
+//
+// It supports the commonly used subset of the specification: `type`, `enum`, `const`, `properties`,
+// `required`, `additionalProperties`, `items`, `minItems`, `maxItems`, `uniqueItems`, `minimum`, `maximum`,
+// `exclusiveMinimum`, `exclusiveMaximum`, `multipleOf`, `minLength`, `maxLength`, `pattern`, `allOf`, `anyOf`,
+// `oneOf`, `not` and local `$ref` (e.g. `#/$defs/device`). The annotations `$schema`, `$id` (root only),
+// `$comment`, `$defs`, `definitions`, `title`, `description`, `default`, `examples`, `deprecated`, `readOnly`
+// and `writeOnly` are accepted. Any other keyword (e.g. `patternProperties` or `format`) fails the compilation,
+// since ignoring it would validate something else than the schema declares.
+type Schema struct {
+	root *node
+}
+
+type node struct {
+	// always is set for boolean schemas (`true` or `false`).
+	always *bool
+
+	ref   string
+	types []string
+	enum  []any
+	cnst  *any
+
+	properties           map[string]*node
+	required             []string
+	additionalProperties *node
+
+	items       *node
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	// target is the node referenced by _ref_, resolved when compiled.
+	target *node
+}
+
+// keywords are the implemented keywords.
+var keywords = map[string]bool{
+	"$ref": true, "type": true, "enum": true, "const": true, "properties": true, "required": true,
+	"additionalProperties": true, "items": true, "minItems": true, "maxItems": true, "uniqueItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"minLength": true, "maxLength": true, "pattern": true, "allOf": true, "anyOf": true, "oneOf": true, "not": true,
+}
+
+// annotations are the keywords that do not affect validation.
+var annotations = map[string]bool{
+	"$schema": true, "$comment": true, "$defs": true, "definitions": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// compiler compiles a schema document.
+type compiler struct {
+	// doc is the raw root document, used to resolve `$ref`.
+	doc any
+	// refs holds the nodes compiled for each `$ref`, so each is compiled once and recursive schemas terminate.
+	refs map[string]*node
+}
+
+// ValidationError is returned when a value does not conform to the `Schema`.
+type ValidationError struct {
+	// Path is the JSON path of the failing value, e.g. `$.data.items[2].temp`.
+	Path string
+	// Reason describes the failure.
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Unwrap makes a `ValidationError` match `types.ErrInvalidPayload`.
+func (e *ValidationError) Unwrap() error {
+	return types.ErrInvalidPayload
+}
+
+// Compile compiles the JSON encoded schema _data_.
+func Compile(data []byte) (*Schema, error) {
+	var doc any
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	c := &compiler{doc: doc, refs: map[string]*node{}}
+
+	root, err := c.resolve("#", "#")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCycles(root); err != nil {
+		return nil, err
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// Load reads and compiles the schema in the local file at _path_.
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON schema %q: %w", path, err)
+	}
+
+	s, err := Compile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Validate validates the decoded JSON _value_ (as produced by `json.Unmarshal` into `any`).
+//
+// It returns a `*ValidationError` on the first failure.
+func (s *Schema) Validate(value any) error {
+	return s.root.validate(value, "$")
+}
+
+// ValidateJSON decodes and validates the JSON _data_. Invalid JSON is returned as a `*ValidationError` on `$`.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &ValidationError{Path: "$", Reason: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	return s.Validate(value)
+}
+
+func (c *compiler) compile(raw any, at string) (*node, error) {
+	if b, ok := raw.(bool); ok {
+		return &node{always: &b}, nil
+	}
+
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON schema at %s: expected object or boolean", at)
+	}
+
+	for _, kw := range slices.Sorted(maps.Keys(m)) {
+		if !keywords[kw] && !annotations[kw] && (kw != "$id" || at != "#") {
+			return nil, fmt.Errorf("invalid JSON schema at %s: unsupported keyword %q", at, kw)
+		}
+	}
+
+	n := &node{}
+
+	if ref, ok := m["$ref"].(string); ok {
+		if !strings.HasPrefix(ref, "#") {
+			return nil, fmt.Errorf("invalid JSON schema at %s: only local $ref is supported, got %q", at, ref)
+		}
+
+		target, err := c.resolve(ref, at)
+		if err != nil {
+			return nil, err
+		}
+
+		n.ref, n.target = ref, target
+	}
+
+	switch t := m["type"].(type) {
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				n.types = append(n.types, s)
+			}
+		}
+	}
+
+	if e, ok := m["enum"].([]any); ok {
+		n.enum = e
+	}
+
+	if c, ok := m["const"]; ok {
+		n.cnst = &c
+	}
+
+	if props, ok := m["properties"].(map[string]any); ok {
+		n.properties = map[string]*node{}
+
+		for name, p := range props {
+			child, err := c.compile(p, at+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+
+			n.properties[name] = child
+		}
+	}
+
+	if req, ok := m["required"].([]any); ok {
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				n.required = append(n.required, s)
+			}
+		}
+	}
+
+	var err error
+
+	if n.additionalProperties, err = c.optional(m, "additionalProperties", at); err != nil {
+		return nil, err
+	}
+
+	if n.items, err = c.optional(m, "items", at); err != nil {
+		return nil, err
+	}
+
+	if n.not, err = c.optional(m, "not", at); err != nil {
+		return nil, err
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  *[]*node
+	}{{"allOf", &n.allOf}, {"anyOf", &n.anyOf}, {"oneOf", &n.oneOf}} {
+		list, _ := m[kw.name].([]any)
+
+		for i, s := range list {
+			child, err := c.compile(s, at+"/"+kw.name+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+
+			*kw.dst = append(*kw.dst, child)
+		}
+	}
+
+	n.minItems, n.maxItems = intPtr(m["minItems"]), intPtr(m["maxItems"])
+	n.minLength, n.maxLength = intPtr(m["minLength"]), intPtr(m["maxLength"])
+	n.uniqueItems, _ = m["uniqueItems"].(bool)
+	n.minimum, n.maximum = floatPtr(m["minimum"]), floatPtr(m["maximum"])
+	n.exclusiveMinimum, n.exclusiveMaximum = floatPtr(m["exclusiveMinimum"]), floatPtr(m["exclusiveMaximum"])
+	n.multipleOf = floatPtr(m["multipleOf"])
+
+	if p, ok := m["pattern"].(string); ok {
+		if n.pattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid JSON schema at %s: invalid pattern: %w", at, err)
+		}
+	}
+
+	return n, nil
+}
+
+func (c *compiler) optional(m map[string]any, key string, at string) (*node, error) {
+	raw, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return c.compile(raw, at+"/"+key)
+}
+
+// resolve returns the node of the local _ref_ (a JSON pointer such as `#/$defs/device`) referenced at _at_.
+//
+// Each reference is compiled once. A placeholder is registered before compiling, so a recursive reference
+// gets the same node.
+func (c *compiler) resolve(ref, at string) (*node, error) {
+	if n, ok := c.refs[ref]; ok {
+		return n, nil
+	}
+
+	cur := c.doc
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if part == "" {
+			continue
+		}
+
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON schema at %s: unresolvable $ref %q", at, ref)
+		}
+
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("invalid JSON schema at %s: unresolvable $ref %q", at, ref)
+		}
+	}
+
+	n := &node{}
+	c.refs[ref] = n
+
+	compiled, err := c.compile(cur, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	*n = *compiled
+
+	return n, nil
+}
+
+// checkCycles returns an error if a schema, through `$ref` or the combinators, applies to the same value
+// again without descending into it (e.g. `{"$ref": "#"}`), since validation would never terminate.
+func checkCycles(root *node) error {
+	const (
+		visiting = iota + 1
+		done
+	)
+
+	var (
+		state = map[*node]int{}
+		// pending are the schemas of nested values, each is checked from scratch since descending into a
+		// nested value makes progress.
+		pending = []*node{root}
+		visit   func(n *node) error
+	)
+
+	visit = func(n *node) error {
+		switch state[n] {
+		case visiting:
+			return fmt.Errorf("invalid JSON schema: $ref %q is a cycle that never validates a nested value", n.ref)
+		case done:
+			return nil
+		}
+
+		state[n] = visiting
+
+		same := slices.Concat(n.allOf, n.anyOf, n.oneOf)
+		if n.target != nil {
+			same = append(same, n.target)
+		}
+
+		if n.not != nil {
+			same = append(same, n.not)
+		}
+
+		for _, s := range same {
+			if err := visit(s); err != nil {
+				return err
+			}
+		}
+
+		state[n] = done
+
+		for _, name := range slices.Sorted(maps.Keys(n.properties)) {
+			pending = append(pending, n.properties[name])
+		}
+
+		for _, s := range []*node{n.additionalProperties, n.items} {
+			if s != nil {
+				pending = append(pending, s)
+			}
+		}
+
+		return nil
+	}
+
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validate(v any, path string) error {
+	if n.always != nil {
+		if *n.always {
+			return nil
+		}
+
+		return fail(path, "no value is allowed")
+	}
+
+	if n.target != nil {
+		if err := n.target.validate(v, path); err != nil {
+			return err
+		}
+	}
+
+	if len(n.types) > 0 && !n.matchesType(v) {
+		return fail(path, fmt.Sprintf("expected type %s, got %s", strings.Join(n.types, " or "), typeOf(v)))
+	}
+
+	if n.enum != nil && !containsValue(n.enum, v) {
+		return fail(path, "value is not one of the enumerated values")
+	}
+
+	if n.cnst != nil && !reflect.DeepEqual(*n.cnst, v) {
+		return fail(path, "value does not equal the constant")
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		if err := n.validateObject(t, path); err != nil {
+			return err
+		}
+	case []any:
+		if err := n.validateArray(t, path); err != nil {
+			return err
+		}
+	case float64:
+		if err := n.validateNumber(t, path); err != nil {
+			return err
+		}
+	case string:
+		if err := n.validateString(t, path); err != nil {
+			return err
+		}
+	}
+
+	return n.validateCombinators(v, path)
+}
+
+func (n *node) validateObject(obj map[string]any, path string) error {
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			return fail(childPath(path, name), "required property is missing")
+		}
+	}
+
+	// Sorted, so the reported path is deterministic when several properties are invalid.
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		value := obj[name]
+
+		if p, ok := n.properties[name]; ok {
+			if err := p.validate(value, childPath(path, name)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if n.additionalProperties != nil {
+			if err := n.additionalProperties.validate(value, childPath(path, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validateArray(arr []any, path string) error {
+	if n.minItems != nil && len(arr) < *n.minItems {
+		return fail(path, fmt.Sprintf("expected at least %d items, got %d", *n.minItems, len(arr)))
+	}
+
+	if n.maxItems != nil && len(arr) > *n.maxItems {
+		return fail(path, fmt.Sprintf("expected at most %d items, got %d", *n.maxItems, len(arr)))
+	}
+
+	if n.uniqueItems {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					return fail(fmt.Sprintf("%s[%d]", path, j), fmt.Sprintf("duplicate of item %d", i))
+				}
+			}
+		}
+	}
+
+	if n.items != nil {
+		for i, item := range arr {
+			if err := n.items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validateNumber(f float64, path string) error {
+	switch {
+	case n.minimum != nil && f < *n.minimum:
+		return fail(path, fmt.Sprintf("%v is less than minimum %v", f, *n.minimum))
+	case n.maximum != nil && f > *n.maximum:
+		return fail(path, fmt.Sprintf("%v is greater than maximum %v", f, *n.maximum))
+	case n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum:
+		return fail(path, fmt.Sprintf("%v is not greater than %v", f, *n.exclusiveMinimum))
+	case n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum:
+		return fail(path, fmt.Sprintf("%v is not less than %v", f, *n.exclusiveMaximum))
+	case n.multipleOf != nil && *n.multipleOf != 0 && !isInteger(f / *n.multipleOf):
+		return fail(path, fmt.Sprintf("%v is not a multiple of %v", f, *n.multipleOf))
+	default:
+		return nil
+	}
+}
+
+func (n *node) validateString(s, path string) error {
+	length := utf8.RuneCountInString(s)
+
+	switch {
+	case n.minLength != nil && length < *n.minLength:
+		return fail(path, fmt.Sprintf("expected at least %d characters, got %d", *n.minLength, length))
+	case n.maxLength != nil && length > *n.maxLength:
+		return fail(path, fmt.Sprintf("expected at most %d characters, got %d", *n.maxLength, length))
+	case n.pattern != nil && !n.pattern.MatchString(s):
+		return fail(path, fmt.Sprintf("does not match pattern %q", n.pattern.String()))
+	default:
+		return nil
+	}
+}
+
+func (n *node) validateCombinators(v any, path string) error {
+	for _, s := range n.allOf {
+		if err := s.validate(v, path); err != nil {
+			return err
+		}
+	}
+
+	if len(n.anyOf) > 0 {
+		matched := false
+
+		for _, s := range n.anyOf {
+			if s.validate(v, path) == nil {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fail(path, "value does not match any of the anyOf schemas")
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		matched := 0
+
+		for _, s := range n.oneOf {
+			if s.validate(v, path) == nil {
+				matched++
+			}
+		}
+
+		if matched != 1 {
+			return fail(path, fmt.Sprintf("value must match exactly one of the oneOf schemas, matched %d", matched))
+		}
+	}
+
+	if n.not != nil && n.not.validate(v, path) == nil {
+		return fail(path, "value must not match the not schema")
+	}
+
+	return nil
+}
+
+func (n *node) matchesType(v any) bool {
+	actual := typeOf(v)
+
+	for _, t := range n.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func typeOf(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if isInteger(t) {
+			return "integer"
+		}
+
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func isInteger(f float64) bool {
+	return !math.IsInf(f, 0) && f == math.Trunc(f)
+}
+
+func containsValue(list []any, v any) bool {
+	for _, e := range list {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func childPath(path, name string) string {
+	for _, r := range name {
+		if !(r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			return path + "[" + strconv.Quote(name) + "]"
+		}
+	}
+
+	return path + "." + name
+}
+
+func fail(path, reason string) error {
+	return &ValidationError{Path: path, Reason: reason}
+}
+
+func intPtr(v any) *int {
+	if f, ok := v.(float64); ok {
+		i := int(f)
+		return &i
+	}
+
+	return nil
+}
+
+func floatPtr(v any) *float64 {
+	if f, ok := v.(float64); ok {
+		return &f
+	}
+
+	return nil
+}
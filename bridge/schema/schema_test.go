@@ -0,0 +1,131 @@
+package schema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/schema"
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const telemetrySchema = `{
+	"type": "object",
+	"required": ["id", "readings"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^dev-[0-9]+$"},
+		"mode": {"enum": ["eco", "boost"]},
+		"readings": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/reading"}}
+	},
+	"$defs": {
+		"reading": {
+			"type": "object",
+			"required": ["temp"],
+			"properties": {"temp": {"type": "number", "minimum": -40, "maximum": 125}}
+		}
+	}
+}`
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	s, err := schema.Compile([]byte(telemetrySchema))
+	require.NoError(t, err)
+
+	require.NoError(t, s.ValidateJSON([]byte(`{"id": "dev-1", "mode": "eco", "readings": [{"temp": 21.5}]}`)))
+
+	tests := []struct {
+		payload string
+		path    string
+	}{
+		{`{"readings": [{"temp": 1}]}`, "$.id"},
+		{`{"id": "x", "readings": [{"temp": 1}]}`, "$.id"},
+		{`{"id": "dev-1", "readings": []}`, "$.readings"},
+		{`{"id": "dev-1", "readings": [{"temp": 1}, {"temp": 200}]}`, "$.readings[1].temp"},
+		{`{"id": "dev-1", "readings": [{"temp": "hot"}]}`, "$.readings[0].temp"},
+		{`{"id": "dev-1", "mode": "off", "readings": [{"temp": 1}]}`, "$.mode"},
+		// Several invalid properties are reported in key order.
+		{`{"readings": [], "mode": "off", "id": "dev-1"}`, "$.mode"},
+		{`{"id": "dev-1", "readings": [{"temp": 1}], "extra-field": 1}`, `$["extra-field"]`},
+		{`not json`, "$"},
+	}
+
+	for _, tc := range tests {
+		err := s.ValidateJSON([]byte(tc.payload))
+
+		var verr *schema.ValidationError
+		require.ErrorAs(t, err, &verr, tc.payload)
+		assert.Equal(t, tc.path, verr.Path, tc.payload)
+		assert.ErrorIs(t, err, types.ErrInvalidPayload)
+	}
+}
+
+func TestRegistry_RegisterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.json")
+	require.NoError(t, os.WriteFile(path, []byte(telemetrySchema), 0o600))
+
+	reg := schema.NewRegistry(topic.SyntaxMQTT)
+	require.NoError(t, reg.RegisterFile("devices/+/telemetry", path))
+
+	assert.Len(t, reg.Lookup("devices/1/telemetry"), 1)
+	assert.Empty(t, reg.Lookup("devices/1/state"))
+	assert.Error(t, reg.RegisterFile("devices/#", filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestCompile_RejectsUnsupportedKeywords(t *testing.T) {
+	for _, kw := range []string{
+		`"patternProperties": {"^x-": {"type": "string"}}`,
+		`"prefixItems": [{"type": "string"}]`,
+		`"if": {"required": ["a"]}, "then": {"required": ["b"]}`,
+		`"dependentRequired": {"a": ["b"]}`,
+		`"propertyNames": {"maxLength": 3}`,
+		`"format": "email"`,
+		`"properties": {"a": {"$id": "a.json"}}`,
+	} {
+		_, err := schema.Compile([]byte(`{"type": "object", ` + kw + `}`))
+		assert.ErrorContains(t, err, "unsupported keyword", kw)
+	}
+
+	_, err := schema.Compile([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema", "$id": "telemetry.json", "$comment": "c",
+		"title": "t", "description": "d", "default": {}, "examples": [{}], "deprecated": false,
+		"properties": {"a": {"readOnly": true, "writeOnly": false}}, "definitions": {}
+	}`))
+	assert.NoError(t, err)
+}
+
+func TestCompile_RefCycles(t *testing.T) {
+	for _, src := range []string{
+		`{"$ref": "#"}`,
+		`{"anyOf": [{"type": "string"}, {"$ref": "#"}]}`,
+		`{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"allOf": [{"$ref": "#/$defs/a"}]}}}`,
+		`{"properties": {"x": {"not": {"$ref": "#/properties/x"}}}}`,
+	} {
+		_, err := schema.Compile([]byte(src))
+		assert.ErrorContains(t, err, "cycle", src)
+	}
+
+	_, err := schema.Compile([]byte(`{"$ref": "#/$defs/missing"}`))
+	assert.ErrorContains(t, err, "unresolvable")
+
+	// Recursion through a nested value makes progress.
+	tree, err := schema.Compile([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$ref": "#"}}}
+	}`))
+	require.NoError(t, err)
+
+	require.NoError(t, tree.ValidateJSON([]byte(`{"name": "a", "children": [{"name": "b", "children": [{"name": "c"}]}]}`)))
+
+	var verr *schema.ValidationError
+	require.ErrorAs(t, tree.ValidateJSON([]byte(`{"name": "a", "children": [{"children": []}]}`)), &verr)
+	assert.Equal(t, "$.children[0].name", verr.Path)
+
+	nested, err := schema.Compile([]byte(`{"allOf": [{"type": "array", "items": {"$ref": "#"}}]}`))
+	require.NoError(t, err)
+	assert.NoError(t, nested.ValidateJSON([]byte(`[[], [[]]]`)))
+	assert.Error(t, nested.ValidateJSON([]byte(`[[1]]`)))
+}
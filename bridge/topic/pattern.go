@@ -0,0 +1,288 @@
+package topic
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Syntax is the wildcard syntax of a topic pattern.
+type Syntax int
+
+const (
+	// SyntaxMQTT uses `/` as level separator, `+` for exactly one level and `#` (last level only)
+	// for zero or more levels.
+	SyntaxMQTT Syntax = iota
+	// SyntaxAMQP uses `.` as word separator, `*` for exactly one word and `#` for zero or more words.
+	SyntaxAMQP
+	// SyntaxGlob uses `/` as level separator, `*` for exactly one level, `**` for zero or more levels
+	// and `path.Match` patterns (e.g. `dev-*`) within a level.
+	SyntaxGlob
+)
+
+// Separator returns the level separator of the syntax.
+func (s Syntax) Separator() string {
+	if s == SyntaxAMQP {
+		return "."
+	}
+
+	return "/"
+}
+
+// String returns the name of the syntax.
+func (s Syntax) String() string {
+	switch s {
+	case SyntaxMQTT:
+		return "mqtt"
+	case SyntaxAMQP:
+		return "amqp"
+	case SyntaxGlob:
+		return "glob"
+	default:
+		return fmt.Sprintf("syntax(%d)", int(s))
+	}
+}
+
+// MarshalText implements `encoding.TextMarshaler`.
+func (s Syntax) MarshalText() ([]byte, error) {
+	switch s {
+	case SyntaxMQTT, SyntaxAMQP, SyntaxGlob:
+		return []byte(s.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown topic syntax %d", int(s))
+	}
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`. It accepts _mqtt_, _amqp_ and _glob_ (case-insensitive).
+func (s *Syntax) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "mqtt", "":
+		*s = SyntaxMQTT
+	case "amqp":
+		*s = SyntaxAMQP
+	case "glob":
+		*s = SyntaxGlob
+	default:
+		return fmt.Errorf("unknown topic syntax %q", string(text))
+	}
+
+	return nil
+}
+
+type tokenKind int
+
+const (
+	tokenLiteral tokenKind = iota
+	// tokenSingle matches exactly one level.
+	tokenSingle
+	// tokenMulti matches zero or more levels.
+	tokenMulti
+	// tokenGlob matches exactly one level using `path.Match`.
+	tokenGlob
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// name is the optional capture name of a wildcard (e.g. `id` in `+id`).
+	name string
+}
+
+// key returns the trie edge key of the token. Wildcards of the same kind share edge regardless of name.
+func (t token) key() string {
+	switch t.kind {
+	case tokenSingle:
+		return "\x00+"
+	case tokenMulti:
+		return "\x00#"
+	case tokenGlob:
+		return "\x00g" + t.value
+	default:
+		return t.value
+	}
+}
+
+// Pattern is a parsed topic pattern.
+type Pattern struct {
+	raw    string
+	syntax Syntax
+	tokens []token
+	// matcher is the single pattern trie used by `Match`, compiled once by `Parse`.
+	matcher *Trie[struct{}]
+}
+
+// Captures holds the values matched by the wildcards of a `Pattern`.
+type Captures struct {
+	// Values are the values of all wildcards in the order they appear in the pattern. A multi level wildcard
+	// value is the matched levels joined by the separator.
+	Values []string
+	// Named holds the values of the named wildcards (e.g. `+id` in _MQTT_ syntax).
+	Named map[string]string
+}
+
+// Parse parses the _pattern_ using the _syntax_.
+//
+// Wildcards may be named by suffixing the wildcard character, e.g. `devices/+id/#rest` (_MQTT_) or
+// `devices.*id.#rest` (_AMQP_). Glob wildcards are not named but are still captured positionally.
+//
+// It returns a `types.ErrSubscriptionInvalidTopicName` wrapped error if the _pattern_ is invalid.
+func Parse(pattern string, syntax Syntax) (*Pattern, error) {
+	if pattern == "" {
+		return nil, invalid(pattern, "empty pattern")
+	}
+
+	levels := strings.Split(pattern, syntax.Separator())
+	tokens := make([]token, 0, len(levels))
+
+	for i, level := range levels {
+		tok, err := parseLevel(level, syntax)
+		if err != nil {
+			return nil, invalid(pattern, err.Error())
+		}
+
+		if syntax == SyntaxMQTT && tok.kind == tokenMulti && i != len(levels)-1 {
+			return nil, invalid(pattern, "'#' must be the last level")
+		}
+
+		tokens = append(tokens, tok)
+	}
+
+	p := &Pattern{raw: pattern, syntax: syntax, tokens: tokens, matcher: NewTrie[struct{}](syntax)}
+	p.matcher.insert(p, struct{}{})
+
+	return p, nil
+}
+
+// MustParse is like `Parse` but panics on error.
+func MustParse(pattern string, syntax Syntax) *Pattern {
+	p, err := Parse(pattern, syntax)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// String returns the raw pattern.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Subscription renders the pattern without the wildcard capture names, e.g. `devices/+id/#rest` as
+// `devices/+/#`, i.e. as understood by a broker. The capture names are only known to the local `Match`.
+func (p *Pattern) Subscription() string {
+	levels := make([]string, len(p.tokens))
+
+	for i, t := range p.tokens {
+		switch {
+		case t.kind == tokenSingle && p.syntax == SyntaxMQTT:
+			levels[i] = "+"
+		case t.kind == tokenSingle:
+			levels[i] = "*"
+		case t.kind == tokenMulti && p.syntax == SyntaxGlob:
+			levels[i] = "**"
+		case t.kind == tokenMulti:
+			levels[i] = "#"
+		default:
+			levels[i] = t.value
+		}
+	}
+
+	return strings.Join(levels, p.syntax.Separator())
+}
+
+// Syntax returns the syntax of the pattern.
+func (p *Pattern) Syntax() Syntax {
+	return p.syntax
+}
+
+// IsWildcard returns `true` if the pattern contains any wildcards.
+func (p *Pattern) IsWildcard() bool {
+	for _, t := range p.tokens {
+		if t.kind != tokenLiteral {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match matches the concrete _topic_ against the pattern and returns the wildcard captures.
+func (p *Pattern) Match(topic string) (Captures, bool) {
+	matches := p.matcher.Match(topic)
+	if len(matches) == 0 {
+		return Captures{}, false
+	}
+
+	return matches[0].Captures, true
+}
+
+// names returns the capture names of the wildcards in the order they appear.
+func (p *Pattern) names() []string {
+	var names []string
+
+	for _, t := range p.tokens {
+		if t.kind != tokenLiteral {
+			names = append(names, t.name)
+		}
+	}
+
+	return names
+}
+
+func parseLevel(level string, syntax Syntax) (token, error) {
+	switch syntax {
+	case SyntaxMQTT:
+		return parseNamed(level, "+", "#")
+	case SyntaxAMQP:
+		return parseNamed(level, "*", "#")
+	case SyntaxGlob:
+		switch {
+		case level == "**":
+			return token{kind: tokenMulti}, nil
+		case level == "*":
+			return token{kind: tokenSingle}, nil
+		case strings.Contains(level, "**"):
+			return token{}, fmt.Errorf("'**' must be a whole level in %q", level)
+		case strings.ContainsAny(level, `*?[\`):
+			if _, err := path.Match(level, ""); err != nil {
+				return token{}, fmt.Errorf("invalid glob level %q: %w", level, err)
+			}
+
+			return token{kind: tokenGlob, value: level}, nil
+		default:
+			return token{kind: tokenLiteral, value: level}, nil
+		}
+	default:
+		return token{}, fmt.Errorf("unknown syntax %s", syntax)
+	}
+}
+
+func parseNamed(level, single, multi string) (token, error) {
+	switch {
+	case strings.HasPrefix(level, single):
+		name := level[len(single):]
+		if strings.ContainsAny(name, single+multi) {
+			return token{}, fmt.Errorf("invalid wildcard level %q", level)
+		}
+
+		return token{kind: tokenSingle, name: name}, nil
+	case strings.HasPrefix(level, multi):
+		name := level[len(multi):]
+		if strings.ContainsAny(name, single+multi) {
+			return token{}, fmt.Errorf("invalid wildcard level %q", level)
+		}
+
+		return token{kind: tokenMulti, name: name}, nil
+	case strings.ContainsAny(level, single+multi):
+		return token{}, fmt.Errorf("wildcard must be a whole level in %q", level)
+	default:
+		return token{kind: tokenLiteral, value: level}, nil
+	}
+}
+
+func invalid(pattern, reason string) error {
+	return fmt.Errorf("%w: %q: %s", types.ErrSubscriptionInvalidTopicName, pattern, reason)
+}
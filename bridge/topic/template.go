@@ -0,0 +1,132 @@
+package topic
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Template is a parsed topic template where `{name}` is replaced by the named capture and `{0}`, `{1}`, ...
+// by the positional captures of a matched `Pattern`. The placeholder `{topic}` is replaced by the whole
+// source topic unless a capture is named _topic_. Use `{{` and `}}` for literal braces.
+type Template struct {
+	raw   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal string
+	// ref is the capture name (or index) when not a literal.
+	ref   string
+	isRef bool
+}
+
+// ParseTemplate parses the _template_. If _pattern_ is not `nil`, all placeholders are validated against the
+// captures of the _pattern_.
+//
+// It returns a `types.ErrInvalidTopicName` wrapped error if the _template_ is invalid.
+func ParseTemplate(template string, pattern *Pattern) (*Template, error) {
+	t := &Template{raw: template}
+
+	var literal strings.Builder
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+
+		switch {
+		case c == '{' && i+1 < len(template) && template[i+1] == '{':
+			literal.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(template) && template[i+1] == '}':
+			literal.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated placeholder in template %q", types.ErrInvalidTopicName, template)
+			}
+
+			ref := template[i+1 : i+end]
+			if ref == "" {
+				return nil, fmt.Errorf("%w: empty placeholder in template %q", types.ErrInvalidTopicName, template)
+			}
+
+			if pattern != nil && !pattern.hasCapture(ref) {
+				return nil, fmt.Errorf(
+					"%w: placeholder {%s} in template %q is not captured by pattern %q",
+					types.ErrInvalidTopicName, ref, template, pattern.raw,
+				)
+			}
+
+			if literal.Len() > 0 {
+				t.parts = append(t.parts, templatePart{literal: literal.String()})
+				literal.Reset()
+			}
+
+			t.parts = append(t.parts, templatePart{ref: ref, isRef: true})
+			i += end
+		case c == '}':
+			return nil, fmt.Errorf("%w: unbalanced '}' in template %q", types.ErrInvalidTopicName, template)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, templatePart{literal: literal.String()})
+	}
+
+	return t, nil
+}
+
+// String returns the raw template.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Render renders the template using the _captures_ from matching the source _topic_.
+//
+// It returns a `types.ErrInvalidTopicName` wrapped error if a placeholder is not present in _captures_.
+func (t *Template) Render(topic string, captures Captures) (string, error) {
+	var sb strings.Builder
+
+	for _, p := range t.parts {
+		if !p.isRef {
+			sb.WriteString(p.literal)
+			continue
+		}
+
+		value, ok := captures.Named[p.ref]
+		if !ok {
+			if idx, err := strconv.Atoi(p.ref); err == nil && idx >= 0 && idx < len(captures.Values) {
+				value, ok = captures.Values[idx], true
+			} else if p.ref == "topic" {
+				value, ok = topic, true
+			}
+		}
+
+		if !ok {
+			return "", fmt.Errorf("%w: placeholder {%s} in template %q has no capture", types.ErrInvalidTopicName, p.ref, t.raw)
+		}
+
+		sb.WriteString(value)
+	}
+
+	return sb.String(), nil
+}
+
+// hasCapture returns `true` if _ref_ is a capture name, a valid capture index or `topic`.
+func (p *Pattern) hasCapture(ref string) bool {
+	names := p.names()
+
+	if slices.Contains(names, ref) || ref == "topic" {
+		return true
+	}
+
+	idx, err := strconv.Atoi(ref)
+
+	return err == nil && idx >= 0 && idx < len(names)
+}
@@ -0,0 +1,29 @@
+package topic_test
+
+import (
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	p := topic.MustParse("devices/+id/#rest", topic.SyntaxMQTT)
+
+	tmpl, err := topic.ParseTemplate("sb://telemetry?device={id}&rest={1}&src={topic}&x={{y}}", p)
+	require.NoError(t, err)
+
+	captures, ok := p.Match("devices/42/a/b")
+	require.True(t, ok)
+
+	got, err := tmpl.Render("devices/42/a/b", captures)
+	require.NoError(t, err)
+	assert.Equal(t, "sb://telemetry?device=42&rest=a/b&src=devices/42/a/b&x={y}", got)
+
+	for _, invalid := range []string{"a/{missing}", "a/{2}", "a/{id", "a/}", "a/{}"} {
+		_, err := topic.ParseTemplate(invalid, p)
+		assert.ErrorIs(t, err, types.ErrInvalidTopicName, invalid)
+	}
+}
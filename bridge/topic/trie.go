@@ -0,0 +1,271 @@
+package topic
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Match is a matching entry in a `Trie`.
+type Match[V any] struct {
+	// Pattern is the pattern that matched.
+	Pattern *Pattern
+	// Value is the value registered with the pattern.
+	Value V
+	// Captures holds the wildcard captures of the match.
+	Captures Captures
+}
+
+// Trie indexes topic patterns of a single `Syntax` for efficient matching of concrete topics.
+//
+// Many values may be registered on the same pattern. It is safe for concurrent use.
+type Trie[V any] struct {
+	syntax Syntax
+	mu     sync.RWMutex
+	root   *node[V]
+	seq    uint64
+}
+
+type node[V any] struct {
+	tok token
+	// children holds all child nodes keyed by `token.key`, i.e. literals by their value.
+	children map[string]*node[V]
+	// wildcards holds the non literal children, which are the only ones that must be scanned when matching.
+	wildcards []*node[V]
+	entries   []*entry[V]
+}
+
+type entry[V any] struct {
+	pattern *Pattern
+	value   V
+	// seq is the registration order of the entry.
+	seq uint64
+}
+
+// NewTrie creates a new, empty, `Trie` for patterns of _syntax_.
+func NewTrie[V any](syntax Syntax) *Trie[V] {
+	return &Trie[V]{syntax: syntax, root: &node[V]{}}
+}
+
+// Syntax returns the syntax of the patterns in the trie.
+func (t *Trie[V]) Syntax() Syntax {
+	return t.syntax
+}
+
+// Insert parses the _pattern_ and registers _value_ on it.
+func (t *Trie[V]) Insert(pattern string, value V) (*Pattern, error) {
+	p, err := Parse(pattern, t.syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	t.insert(p, value)
+
+	return p, nil
+}
+
+// Remove removes all values on _pattern_ for which _fn_ returns `true` and returns the number removed.
+func (t *Trie[V]) Remove(pattern string, fn func(value V) bool) int {
+	p, err := Parse(pattern, t.syntax)
+	if err != nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// nodes is the path from the root to the node of the pattern.
+	nodes := make([]*node[V], 0, len(p.tokens)+1)
+	nodes = append(nodes, t.root)
+
+	n := t.root
+	for _, tok := range p.tokens {
+		if n = n.children[tok.key()]; n == nil {
+			return 0
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	var removed int
+
+	kept := n.entries[:0]
+	for _, e := range n.entries {
+		if e.pattern.raw == p.raw && fn(e.value) {
+			removed++
+			continue
+		}
+
+		kept = append(kept, e)
+	}
+
+	clear(n.entries[len(kept):])
+	n.entries = kept
+
+	// Prune the nodes that no longer lead to any entry on the way back up.
+	for i := len(nodes) - 1; i > 0; i-- {
+		child := nodes[i]
+		if len(child.entries) > 0 || len(child.children) > 0 {
+			break
+		}
+
+		nodes[i-1].remove(child)
+	}
+
+	return removed
+}
+
+// Values returns all values registered on exactly _pattern_.
+func (t *Trie[V]) Values(pattern string) []V {
+	p, err := Parse(pattern, t.syntax)
+	if err != nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := t.root
+	for _, tok := range p.tokens {
+		if n = n.children[tok.key()]; n == nil {
+			return nil
+		}
+	}
+
+	var values []V
+
+	for _, e := range n.entries {
+		if e.pattern.raw == p.raw {
+			values = append(values, e.value)
+		}
+	}
+
+	return values
+}
+
+// Match returns all entries whose pattern matches the concrete _topic_ sorted in registration order.
+func (t *Trie[V]) Match(topic string) []Match[V] {
+	levels := strings.Split(topic, t.syntax.Separator())
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var (
+		matched []*entry[V]
+		values  = map[*entry[V]][]string{}
+		// reserved is `true` for _MQTT_ topics starting with `$` that must not be matched by a first level wildcard.
+		reserved = t.syntax == SyntaxMQTT && strings.HasPrefix(topic, "$")
+	)
+
+	var walk func(n *node[V], i int, captures []string)
+
+	walk = func(n *node[V], i int, captures []string) {
+		if i == len(levels) {
+			for _, e := range n.entries {
+				if _, ok := values[e]; !ok {
+					values[e] = captures
+					matched = append(matched, e)
+				}
+			}
+		}
+
+		if i < len(levels) {
+			if child, ok := n.children[levels[i]]; ok && child.tok.kind == tokenLiteral {
+				walk(child, i+1, captures)
+			}
+		}
+
+		if i == 0 && reserved {
+			return
+		}
+
+		for _, child := range n.wildcards {
+			switch child.tok.kind {
+			case tokenSingle:
+				if i < len(levels) {
+					walk(child, i+1, append(captures[:len(captures):len(captures)], levels[i]))
+				}
+			case tokenGlob:
+				if i < len(levels) {
+					if ok, _ := path.Match(child.tok.value, levels[i]); ok {
+						walk(child, i+1, append(captures[:len(captures):len(captures)], levels[i]))
+					}
+				}
+			case tokenMulti:
+				for j := i; j <= len(levels); j++ {
+					value := strings.Join(levels[i:j], t.syntax.Separator())
+					walk(child, j, append(captures[:len(captures):len(captures)], value))
+				}
+			}
+		}
+	}
+
+	walk(t.root, 0, nil)
+
+	slices.SortFunc(matched, func(a, b *entry[V]) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	matches := make([]Match[V], 0, len(matched))
+	for _, e := range matched {
+		matches = append(matches, Match[V]{Pattern: e.pattern, Value: e.value, Captures: capture(e.pattern, values[e])})
+	}
+
+	return matches
+}
+
+func (t *Trie[V]) insert(p *Pattern, value V) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.root
+	for _, tok := range p.tokens {
+		if n.children == nil {
+			n.children = map[string]*node[V]{}
+		}
+
+		child, ok := n.children[tok.key()]
+		if !ok {
+			child = &node[V]{tok: token{kind: tok.kind, value: tok.value}}
+			n.children[tok.key()] = child
+
+			if tok.kind != tokenLiteral {
+				n.wildcards = append(n.wildcards, child)
+			}
+		}
+
+		n = child
+	}
+
+	t.seq++
+	n.entries = append(n.entries, &entry[V]{pattern: p, value: value, seq: t.seq})
+}
+
+// remove removes the _child_ node.
+func (n *node[V]) remove(child *node[V]) {
+	delete(n.children, child.tok.key())
+
+	if child.tok.kind != tokenLiteral {
+		n.wildcards = slices.DeleteFunc(n.wildcards, func(c *node[V]) bool { return c == child })
+	}
+}
+
+func capture(p *Pattern, values []string) Captures {
+	c := Captures{Values: append([]string(nil), values...)}
+
+	for i, name := range p.names() {
+		if name == "" || i >= len(values) {
+			continue
+		}
+
+		if c.Named == nil {
+			c.Named = map[string]string{}
+		}
+
+		c.Named[name] = values[i]
+	}
+
+	return c
+}
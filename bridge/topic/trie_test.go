@@ -0,0 +1,124 @@
+package topic_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern_Match(t *testing.T) {
+	tests := []struct {
+		syntax  topic.Syntax
+		pattern string
+		topic   string
+		match   bool
+		values  []string
+	}{
+		{topic.SyntaxMQTT, "a/+/c", "a/b/c", true, []string{"b"}},
+		{topic.SyntaxMQTT, "a/+/c", "a/b/d", false, nil},
+		{topic.SyntaxMQTT, "a/#", "a", true, []string{""}},
+		{topic.SyntaxMQTT, "a/#", "a/b/c", true, []string{"b/c"}},
+		{topic.SyntaxMQTT, "#", "$SYS/broker", false, nil},
+		{topic.SyntaxMQTT, "+/+", "a", false, nil},
+		{topic.SyntaxAMQP, "a.*.c", "a.b.c", true, []string{"b"}},
+		{topic.SyntaxAMQP, "a.#.c", "a.c", true, []string{""}},
+		{topic.SyntaxAMQP, "a.#.c", "a.b.x.c", true, []string{"b.x"}},
+		{topic.SyntaxAMQP, "a.*", "a.b.c", false, nil},
+		{topic.SyntaxGlob, "devices/dev-*/**", "devices/dev-1/x/y", true, []string{"dev-1", "x/y"}},
+		{topic.SyntaxGlob, "devices/*/t", "devices/1/t", true, []string{"1"}},
+		{topic.SyntaxGlob, "devices/dev-?", "devices/dev-12", false, nil},
+	}
+
+	for _, tc := range tests {
+		p, err := topic.Parse(tc.pattern, tc.syntax)
+		require.NoError(t, err, tc.pattern)
+
+		captures, ok := p.Match(tc.topic)
+		assert.Equal(t, tc.match, ok, "%s %s %s", tc.syntax, tc.pattern, tc.topic)
+
+		if tc.match {
+			assert.Equal(t, tc.values, captures.Values, "%s %s %s", tc.syntax, tc.pattern, tc.topic)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		syntax  topic.Syntax
+		pattern string
+	}{
+		{topic.SyntaxMQTT, ""},
+		{topic.SyntaxMQTT, "a/#/b"},
+		{topic.SyntaxMQTT, "a/b+/c"},
+		{topic.SyntaxAMQP, "a.b*"},
+		{topic.SyntaxGlob, "a/x**"},
+		{topic.SyntaxGlob, "a/[x"},
+	} {
+		_, err := topic.Parse(tc.pattern, tc.syntax)
+		assert.ErrorIs(t, err, types.ErrSubscriptionInvalidTopicName, tc.pattern)
+	}
+}
+
+func TestTrie_MatchNamedCapturesInRegistrationOrder(t *testing.T) {
+	trie := topic.NewTrie[string](topic.SyntaxMQTT)
+
+	_, err := trie.Insert("devices/+id/telemetry", "a")
+	require.NoError(t, err)
+	_, err = trie.Insert("devices/#rest", "b")
+	require.NoError(t, err)
+	_, err = trie.Insert("devices/+dev/telemetry", "c")
+	require.NoError(t, err)
+
+	matches := trie.Match("devices/42/telemetry")
+	require.Len(t, matches, 3)
+
+	assert.Equal(t, "a", matches[0].Value)
+	assert.Equal(t, "42", matches[0].Captures.Named["id"])
+	assert.Equal(t, "b", matches[1].Value)
+	assert.Equal(t, "42/telemetry", matches[1].Captures.Named["rest"])
+	assert.Equal(t, "c", matches[2].Value)
+	assert.Equal(t, "42", matches[2].Captures.Named["dev"])
+
+	assert.Equal(t, []string{"a"}, trie.Values("devices/+id/telemetry"))
+	assert.Equal(t, 1, trie.Remove("devices/+id/telemetry", func(v string) bool { return true }))
+	assert.Len(t, trie.Match("devices/42/telemetry"), 2)
+}
+
+func TestTrie_RemovePrunesAndMatchesLiteralsAndWildcards(t *testing.T) {
+	trie := topic.NewTrie[string](topic.SyntaxMQTT)
+
+	for i := range 100 {
+		_, err := trie.Insert(fmt.Sprintf("devices/%d/telemetry", i), "literal")
+		require.NoError(t, err)
+	}
+
+	_, err := trie.Insert("devices/+/telemetry", "single")
+	require.NoError(t, err)
+
+	matches := trie.Match("devices/42/telemetry")
+	require.Len(t, matches, 2)
+	assert.Equal(t, "literal", matches[0].Value)
+	assert.Equal(t, "single", matches[1].Value)
+
+	// Churn on the same wildcard pattern must neither leak nor duplicate nodes.
+	for range 3 {
+		assert.Equal(t, 1, trie.Remove("devices/+/telemetry", func(string) bool { return true }))
+		assert.Len(t, trie.Match("devices/42/telemetry"), 1)
+
+		_, err = trie.Insert("devices/+/telemetry", "single")
+		require.NoError(t, err)
+		assert.Len(t, trie.Match("devices/42/telemetry"), 2)
+	}
+
+	for i := range 100 {
+		assert.Equal(t, 1, trie.Remove(fmt.Sprintf("devices/%d/telemetry", i), func(string) bool { return true }))
+	}
+
+	assert.Equal(t, 1, trie.Remove("devices/+/telemetry", func(string) bool { return true }))
+	assert.Empty(t, trie.Match("devices/42/telemetry"))
+	assert.Empty(t, trie.Values("devices/+/telemetry"))
+}
@@ -0,0 +1,93 @@
+package topic
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+)
+
+// Validator validates a concrete topic, e.g. before publishing it on a transport.
+type Validator func(topic string) error
+
+// ValidatorFor returns the `Validator` for concrete topic names of the _transport_. Unknown transports
+// only rejects empty topics.
+//
+// - MQTT: non-empty valid UTF-8 of at most 65535 bytes without `+`, `#` or NUL characters.
+//
+// - AzureServiceBus: at most 260 characters without whitespace or control characters.
+//
+// - SQS: queue name (or the last path segment of a queue URL) of alphanumeric characters, `-` or `_` with an
+// optional `.fifo` suffix. The full name, including the suffix, must be 1-80 characters.
+//
+// All errors wrap `types.ErrInvalidTopicName`.
+func ValidatorFor(transport types.TransportType) Validator {
+	switch transport {
+	case types.TransportTypeMQTT:
+		return validateMQTT
+	case types.TransportTypeAzureServiceBus:
+		return validateServiceBus
+	case types.TransportTypeSQS:
+		return validateSQS
+	default:
+		return func(topic string) error {
+			if topic == "" {
+				return invalidTopic(topic, "empty topic")
+			}
+
+			return nil
+		}
+	}
+}
+
+func validateMQTT(topic string) error {
+	switch {
+	case topic == "":
+		return invalidTopic(topic, "empty topic")
+	case len(topic) > 65535:
+		return invalidTopic(topic, "longer than 65535 bytes")
+	case !utf8.ValidString(topic):
+		return invalidTopic(topic, "not valid UTF-8")
+	case strings.ContainsAny(topic, "+#\x00"):
+		return invalidTopic(topic, "wildcards or NUL characters are not allowed")
+	default:
+		return nil
+	}
+}
+
+func validateServiceBus(topic string) error {
+	switch {
+	case topic == "":
+		return invalidTopic(topic, "empty topic")
+	case utf8.RuneCountInString(topic) > 260:
+		return invalidTopic(topic, "longer than 260 characters")
+	case strings.IndexFunc(topic, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0:
+		return invalidTopic(topic, "whitespace or control characters are not allowed")
+	default:
+		return nil
+	}
+}
+
+func validateSQS(topic string) error {
+	full := topic[strings.LastIndexByte(topic, '/')+1:]
+	name := strings.TrimSuffix(full, ".fifo")
+
+	// The limit applies to the full queue name, including the `.fifo` suffix.
+	if name == "" || len(full) > 80 {
+		return invalidTopic(topic, "queue name must be 1-80 characters")
+	}
+
+	for _, r := range name {
+		if !(r == '-' || r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))) {
+			return invalidTopic(topic, "queue name may only contain alphanumeric characters, '-' and '_'")
+		}
+	}
+
+	return nil
+}
+
+func invalidTopic(topic, reason string) error {
+	return fmt.Errorf("%w: %q: %s", types.ErrInvalidTopicName, topic, reason)
+}
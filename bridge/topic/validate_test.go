@@ -0,0 +1,40 @@
+package topic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mariotoffia/gobridge/bridge/topic"
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorFor_SQSNameLength(t *testing.T) {
+	validate := topic.ValidatorFor(types.TransportTypeSQS)
+
+	for _, tc := range []struct {
+		name  string
+		topic string
+		valid bool
+	}{
+		{"80 characters", strings.Repeat("q", 80), true},
+		{"81 characters", strings.Repeat("q", 81), false},
+		{"80 characters with .fifo", strings.Repeat("q", 75) + ".fifo", true},
+		{"81 characters with .fifo", strings.Repeat("q", 76) + ".fifo", false},
+		{"queue url", "https://sqs.eu-west-1.amazonaws.com/123456789012/" + strings.Repeat("q", 80), true},
+		{"fifo queue url", "https://sqs.eu-west-1.amazonaws.com/123/orders.fifo", true},
+		{"underscore and hyphen", "firmware_manifests-1", true},
+		{"only .fifo", ".fifo", false},
+		{"empty", "", false},
+		{"invalid character", "orders.v1", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate(tc.topic)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, types.ErrInvalidTopicName)
+			}
+		})
+	}
+}
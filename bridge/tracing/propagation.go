@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MetadataKeyTraceParent is the W3C Trace Context _traceparent_ metadata key.
+	MetadataKeyTraceParent = string(types.MessageMetadataKeysTraceParent)
+	// MetadataKeyTraceState is the W3C Trace Context _tracestate_ metadata key.
+	MetadataKeyTraceState = string(types.MessageMetadataKeysTraceState)
+)
+
+// MetadataCarrier adapts the `Metadata` of a `types.Message` to a _OpenTelemetry_ `propagation.TextMapCarrier`.
+//
+// Only `string` values are visible to the propagator.
+type MetadataCarrier map[string]any
+
+// Get returns the value associated with _key_ or an empty string if not a `string`.
+func (c MetadataCarrier) Get(key string) string {
+	s, _ := c[key].(string)
+	return s
+}
+
+// Set sets the _key_ to _value_.
+func (c MetadataCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+// Keys lists the keys having a `string` value.
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for k, v := range c {
+		if _, ok := v.(string); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// ParseTraceParent parses a W3C _traceparent_ header value into a remote `trace.SpanContext`.
+//
+// As mandated by the W3C Trace Context specification, the hex fields must be lower case.
+func ParseTraceParent(value string) (trace.SpanContext, error) {
+	ctx := propagation.TraceContext{}.Extract(
+		context.Background(), MetadataCarrier{MetadataKeyTraceParent: strings.TrimSpace(value)},
+	)
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return trace.SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+
+	return sc, nil
+}
+
+// Inject writes the _traceparent_ and _tracestate_ of the span in _ctx_ into the _metadata_ using the
+// W3C `propagation.TraceContext`.
+func Inject(ctx context.Context, metadata map[string]any) {
+	propagation.TraceContext{}.Inject(ctx, MetadataCarrier(metadata))
+}
+
+// Extract reads the _traceparent_ and _tracestate_ from _metadata_ and returns a copy of _ctx_ carrying the
+// remote span context. If not present, or invalid, _ctx_ is returned as is.
+func Extract(ctx context.Context, metadata map[string]any) context.Context {
+	return propagation.TraceContext{}.Extract(ctx, MetadataCarrier(metadata))
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Acknowledger lets a `Subscriber` explicitly settle a received message instead of relying on the
+// return value of `Subscriber.Process`, e.g. to complete the work asynchronously after `Process` returned.
+//
+// The `SubscriberSource` attaches it to the context passed to `Subscriber.Process` (see `ContextWithAcknowledger`)
+// when the transport supports explicit settlement.
+//
+// Once `Manual`, `Ack`, `Nack` or `DeadLetter` has been called, the `SubscriberSource` do not settle the message
+// based on the return value of `Subscriber.Process`. A message can only be settled once, subsequent calls
+// returns `ErrAlreadySettled`. Operations not supported by the transport returns `ErrProtocolMismatch`.
+type Acknowledger interface {
+	// Manual takes ownership of settling the message without settling it yet.
+	Manual()
+	// Ack acknowledges that the message has been successfully processed.
+	Ack(ctx context.Context) error
+	// Nack rejects the message for re-delivery after _delay_ (when supported, otherwise as soon as possible).
+	Nack(ctx context.Context, delay time.Duration) error
+	// ExtendLease extends the time the message is locked for this consumer by _d_, e.g. the SQS
+	// visibility timeout or the Service Bus message lock.
+	ExtendLease(ctx context.Context, d time.Duration) error
+	// DeadLetter moves the message to the dead-letter queue of the transport with the _reason_.
+	DeadLetter(ctx context.Context, reason error) error
+}
+
+type acknowledgerKey struct{}
+
+// ContextWithAcknowledger returns a copy of _ctx_ carrying the _acknowledger_.
+func ContextWithAcknowledger(ctx context.Context, acknowledger Acknowledger) context.Context {
+	return context.WithValue(ctx, acknowledgerKey{}, acknowledger)
+}
+
+// AcknowledgerFromContext returns the `Acknowledger` attached by the `SubscriberSource`, if any.
+func AcknowledgerFromContext(ctx context.Context) (Acknowledger, bool) {
+	a, ok := ctx.Value(acknowledgerKey{}).(Acknowledger)
+	return a, ok && a != nil
+}
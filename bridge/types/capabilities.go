@@ -1,5 +1,11 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
 type CapabilityType string
 
 const (
@@ -41,13 +47,227 @@ const (
 	// using an in-memory queue until it succeeds or a permanent error occurs. It will, however, not persist
 	// messages to disk for retrying later.
 	CapabilityPublishInMemoryRetryable CapabilityType = "PublishInMemoryRetryable"
+	// CapabilityMaxPayloadSize is the max `Message.Payload` size, in bytes. The value is an `int`.
+	CapabilityMaxPayloadSize CapabilityType = "MaxPayloadSize"
+	// CapabilityMaxTopicLength is the max topic length, in bytes. The value is an `int`.
+	CapabilityMaxTopicLength CapabilityType = "MaxTopicLength"
+	// CapabilityWildcardSyntax is the wildcard syntax supported in subscription topics. The value is a
+	// `WildcardSyntax`.
+	CapabilityWildcardSyntax CapabilityType = "WildcardSyntax"
+	// CapabilityQoS is the set of supported QoS levels (see `QosLevel.Level`). The value is a `[]int`.
+	CapabilityQoS CapabilityType = "QoS"
+	// CapabilityOrdering is the message ordering guarantee. The value is a `Ordering`.
+	CapabilityOrdering CapabilityType = "Ordering"
+	// CapabilityBatch indicates that the `Publisher` implements `BatchPublisher`. The value is a `BatchLimits`.
+	CapabilityBatch CapabilityType = "Batch"
+)
+
+// WildcardSyntax is the wildcard syntax of subscription topics.
+type WildcardSyntax string
+
+const (
+	// WildcardSyntaxNone indicates that wildcards are not supported, only concrete topics.
+	WildcardSyntaxNone WildcardSyntax = "none"
+	// WildcardSyntaxMQTT is the MQTT `+` and `#` wildcard syntax.
+	WildcardSyntaxMQTT WildcardSyntax = "mqtt"
+	// WildcardSyntaxAMQP is the AMQP topic exchange `*` and `#` wildcard syntax.
+	WildcardSyntaxAMQP WildcardSyntax = "amqp"
+	// WildcardSyntaxGlob is the glob `*` and `**` wildcard syntax.
+	WildcardSyntaxGlob WildcardSyntax = "glob"
 )
 
+// Ordering is a message ordering guarantee, from weakest to strongest.
+type Ordering int
+
+const (
+	// OrderingNone do not guarantee any order.
+	OrderingNone Ordering = iota
+	// OrderingPartition guarantees the order of messages with the same partition key
+	// (see `MessageMetadataKeysPartitionKey`), e.g. SQS FIFO message groups or Service Bus sessions.
+	OrderingPartition
+	// OrderingTopic guarantees the order of all messages on the topic.
+	OrderingTopic
+)
+
+// String returns the name of the ordering guarantee.
+func (o Ordering) String() string {
+	switch o {
+	case OrderingPartition:
+		return "partition"
+	case OrderingTopic:
+		return "topic"
+	default:
+		return "none"
+	}
+}
+
+// BatchLimits are the limits of a single `BatchPublisher.PublishBatch` request.
+type BatchLimits struct {
+	// MaxCount is the max number of messages in a batch.
+	MaxCount int `json:"max_count"`
+	// MaxBytes is the max accumulated payload size, in bytes, of a batch. Zero means no limit.
+	MaxBytes int `json:"max_bytes,omitempty"`
+}
+
 // Capability is exposed by the `Connection` to indicate supported features or settings.
+//
+// The _Value_ type depends on the _Type_, see the `CapabilityType` constants. Capabilities without a value
+// are flags, e.g. `CapabilityPublishExactOnce`.
 type Capability struct {
-	Type  string `json:"type"`
-	Value any    `json:"value,omitempty"`
+	Type  CapabilityType `json:"type"`
+	Value any            `json:"value,omitempty"`
+}
+
+// MaxPayloadSize creates a `CapabilityMaxPayloadSize` capability.
+func MaxPayloadSize(bytes int) Capability {
+	return Capability{Type: CapabilityMaxPayloadSize, Value: bytes}
+}
+
+// MaxTopicLength creates a `CapabilityMaxTopicLength` capability.
+func MaxTopicLength(length int) Capability {
+	return Capability{Type: CapabilityMaxTopicLength, Value: length}
+}
+
+// Wildcards creates a `CapabilityWildcardSyntax` capability.
+func Wildcards(syntax WildcardSyntax) Capability {
+	return Capability{Type: CapabilityWildcardSyntax, Value: syntax}
+}
+
+// QoSLevels creates a `CapabilityQoS` capability.
+func QoSLevels(levels ...int) Capability {
+	return Capability{Type: CapabilityQoS, Value: levels}
+}
+
+// Ordered creates a `CapabilityOrdering` capability.
+func Ordered(ordering Ordering) Capability {
+	return Capability{Type: CapabilityOrdering, Value: ordering}
+}
+
+// Batch creates a `CapabilityBatch` capability.
+func Batch(limits BatchLimits) Capability {
+	return Capability{Type: CapabilityBatch, Value: limits}
+}
+
+// UnmarshalJSON decodes the capability and converts the _Value_ into the type of the known `CapabilityType`.
+func (c *Capability) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type  CapabilityType  `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.Type, c.Value = raw.Type, nil
+
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+
+	var target any
+
+	switch raw.Type {
+	case CapabilityMaxPayloadSize, CapabilityMaxTopicLength:
+		target = new(int)
+	case CapabilityWildcardSyntax:
+		target = new(WildcardSyntax)
+	case CapabilityQoS:
+		target = new([]int)
+	case CapabilityOrdering:
+		target = new(Ordering)
+	case CapabilityBatch:
+		target = new(BatchLimits)
+	default:
+		target = new(any)
+	}
+
+	if err := json.Unmarshal(raw.Value, target); err != nil {
+		return fmt.Errorf("capability %q: %w", raw.Type, err)
+	}
+
+	switch v := target.(type) {
+	case *int:
+		c.Value = *v
+	case *WildcardSyntax:
+		c.Value = *v
+	case *[]int:
+		c.Value = *v
+	case *Ordering:
+		c.Value = *v
+	case *BatchLimits:
+		c.Value = *v
+	case *any:
+		c.Value = *v
+	}
+
+	return nil
 }
 
 // Capabilities is a slice of Capability interfaces.
 type Capabilities []Capability
+
+// Get returns the first capability of type _t_.
+func (c Capabilities) Get(t CapabilityType) (Capability, bool) {
+	for _, capability := range c {
+		if capability.Type == t {
+			return capability, true
+		}
+	}
+
+	return Capability{}, false
+}
+
+// Has returns `true` if a capability of type _t_ is present.
+func (c Capabilities) Has(t CapabilityType) bool {
+	_, ok := c.Get(t)
+	return ok
+}
+
+// MaxPayloadSize returns the `CapabilityMaxPayloadSize` value, if present.
+func (c Capabilities) MaxPayloadSize() (int, bool) {
+	return capabilityValue[int](c, CapabilityMaxPayloadSize)
+}
+
+// MaxTopicLength returns the `CapabilityMaxTopicLength` value, if present.
+func (c Capabilities) MaxTopicLength() (int, bool) {
+	return capabilityValue[int](c, CapabilityMaxTopicLength)
+}
+
+// WildcardSyntax returns the `CapabilityWildcardSyntax` value, if present.
+func (c Capabilities) WildcardSyntax() (WildcardSyntax, bool) {
+	return capabilityValue[WildcardSyntax](c, CapabilityWildcardSyntax)
+}
+
+// QoSLevels returns the `CapabilityQoS` value, if present.
+func (c Capabilities) QoSLevels() ([]int, bool) {
+	return capabilityValue[[]int](c, CapabilityQoS)
+}
+
+// SupportsQoS returns `true` if the `CapabilityQoS` is present and contains _level_.
+func (c Capabilities) SupportsQoS(level int) bool {
+	levels, ok := c.QoSLevels()
+	return ok && slices.Contains(levels, level)
+}
+
+// Ordering returns the `CapabilityOrdering` value, if present.
+func (c Capabilities) Ordering() (Ordering, bool) {
+	return capabilityValue[Ordering](c, CapabilityOrdering)
+}
+
+// Batch returns the `CapabilityBatch` value, if present.
+func (c Capabilities) Batch() (BatchLimits, bool) {
+	return capabilityValue[BatchLimits](c, CapabilityBatch)
+}
+
+func capabilityValue[T any](c Capabilities, t CapabilityType) (T, bool) {
+	capability, ok := c.Get(t)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	v, ok := capability.Value.(T)
+
+	return v, ok
+}
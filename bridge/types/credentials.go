@@ -10,6 +10,8 @@ const (
 	CredentialsTypeUsernamePassword CredentialsType = 1
 	// CredentialsTypeTLS is for `TlsCredentials`
 	CredentialsTypeTLS CredentialsType = 2
+	// CredentialsTypeKey is for `KeyCredentials`
+	CredentialsTypeKey CredentialsType = 3
 )
 
 type Credentials struct {
@@ -20,6 +22,7 @@ type Credentials struct {
 	// The object types are determined by the Type field:
 	// - CredentialsTypeUsernamePassword → UsernamePasswordCredentials
 	// - CredentialsTypeTLS → TlsCredentials
+	// - CredentialsTypeKey → KeyCredentials
 	Credentials []any `json:"credentials"`
 }
 
@@ -43,6 +46,35 @@ type TlsCredentials struct {
 	InsecureSkipVerify bool `json:"insecure,omitempty"`
 }
 
+// KeyCredentials is raw key material, e.g. a AES key-encryption key, a HMAC secret or a Ed25519 key.
+type KeyCredentials struct {
+	// KeyID identifies the key, e.g. to select the correct key when it has been rotated.
+	KeyID string `json:"kid"`
+	// Key is the raw key material.
+	Key []byte `json:"key"`
+	// Algorithm optionally binds the key to a single algorithm, e.g. `ed25519`, so it cannot be used with
+	// another algorithm.
+	Algorithm string `json:"alg,omitempty"`
+}
+
+// Key returns the first `KeyCredentials` in the credentials, if any.
+func (c *Credentials) Key() (*KeyCredentials, bool) {
+	for i, t := range c.Type {
+		if t != CredentialsTypeKey || i >= len(c.Credentials) {
+			continue
+		}
+
+		switch k := c.Credentials[i].(type) {
+		case KeyCredentials:
+			return &k, true
+		case *KeyCredentials:
+			return k, k != nil
+		}
+	}
+
+	return nil, false
+}
+
 // CredentialsRepository is used to lookup credentials for a given server URI.
 // It registers itself for a specific URI scheme (e.g., "pms") and optionally a namespace.
 //
@@ -43,6 +43,8 @@ var (
 	//
 	ErrSubscriptionAlreadyExists    = NewBridgeError("subscriber already exists for topic", false, 409)
 	ErrSubscriptionInvalidTopicName = NewBridgeError("topic is not a valid topic", false, 400)
+	// ErrAlreadySettled is returned when a message, already acked, nacked or dead-lettered, is settled again.
+	ErrAlreadySettled = NewBridgeError("message already settled", false, 409)
 	//
 	// Connection related errors
 	//
@@ -103,6 +105,32 @@ func IsBridgeError(err error, target *BridgeError) bool {
 	return errors.Is(err, target)
 }
 
+// AsBridgeError returns the first `BridgeError` in the _err_ chain (including the one embedded
+// in a `BackoffError`). If none is found, it returns `nil` and `false`.
+func AsBridgeError(err error) (*BridgeError, bool) {
+	var backoff *BackoffError
+	if errors.As(err, &backoff) && backoff.BridgeError != nil {
+		return backoff.BridgeError, true
+	}
+
+	var be *BridgeError
+	if errors.As(err, &be) {
+		return be, true
+	}
+
+	return nil, false
+}
+
+// IsRecoverable checks whether _err_ is a recoverable `BridgeError`. Errors that are not
+// `BridgeError` are considered permanent.
+func IsRecoverable(err error) bool {
+	if be, ok := AsBridgeError(err); ok {
+		return be.IsRecoverable
+	}
+
+	return false
+}
+
 type BackoffError struct {
 	*BridgeError
 	RetryAfterSeconds int
@@ -0,0 +1,36 @@
+package types
+
+// NativeHeaders maps the well-known `MessageMetadataKeys` onto the native header (property) of each transport.
+//
+// A `Connection` shall map these keys to and from the native headers when publishing and receiving. Keys not
+// present for a transport, and all other metadata, are carried as custom properties (MQTT 5 user properties,
+// Service Bus application properties and SQS message attributes).
+var NativeHeaders = map[TransportType]map[MessageMetadataKeys]string{
+	TransportTypeMQTT: {
+		MessageMetadataKeysCorrelationID: "Correlation Data",
+		MessageMetadataKeysReplyTo:       "Response Topic",
+		MessageMetadataKeysContentType:   "Content Type",
+	},
+	// The _SessionId_ enables session (FIFO, exclusive) processing on session-enabled entities only, hence the
+	// partition key maps to the _PartitionKey_ of partitioned entities.
+	TransportTypeAzureServiceBus: {
+		MessageMetadataKeysMessageID:     "MessageId",
+		MessageMetadataKeysCorrelationID: "CorrelationId",
+		MessageMetadataKeysReplyTo:       "ReplyTo",
+		MessageMetadataKeysContentType:   "ContentType",
+		MessageMetadataKeysPartitionKey:  "PartitionKey",
+		MessageMetadataKeysDeliveryCount: "DeliveryCount",
+	},
+	// The SQS _MessageId_ is assigned by SQS and the _MessageDeduplicationId_ is not a message id, hence the message
+	// id is carried as a message attribute. _MessageGroupId_ only applies to FIFO queues.
+	TransportTypeSQS: {
+		MessageMetadataKeysPartitionKey:  "MessageGroupId",
+		MessageMetadataKeysDeliveryCount: "ApproximateReceiveCount",
+	},
+}
+
+// NativeHeader returns the native header of _transport_ for the well-known metadata _key_, if any.
+func NativeHeader(transport TransportType, key MessageMetadataKeys) (string, bool) {
+	header, ok := NativeHeaders[transport][key]
+	return header, ok
+}
@@ -1,6 +1,12 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"strconv"
+	"time"
+)
 
 type MessageMetadataKeys string
 
@@ -10,6 +16,46 @@ const (
 	//
 	// It must be a `bool` value.
 	MessageMetadataKeysRetry MessageMetadataKeys = "retry"
+	// MessageID is the unique id of the message, e.g. used for de-duplication.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysMessageID MessageMetadataKeys = "message-id"
+	// CorrelationID correlates a message with another, e.g. a reply with its request.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysCorrelationID MessageMetadataKeys = "correlation-id"
+	// ReplyTo is the topic a reply shall be published on.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysReplyTo MessageMetadataKeys = "reply-to"
+	// ContentType is the MIME content type of the `Message.Payload`, e.g. `application/json`.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysContentType MessageMetadataKeys = "content-type"
+	// ContentEncoding is the encoding (compression) applied to the `Message.Payload`, e.g. `gzip`.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysContentEncoding MessageMetadataKeys = "content-encoding"
+	// PartitionKey is the key messages are ordered (and partitioned) by, e.g. a device id.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysPartitionKey MessageMetadataKeys = "partition-key"
+	// DeliveryCount is the number of times the message has been delivered, set by the `SubscriberSource`.
+	//
+	// It must be a `int` value.
+	MessageMetadataKeysDeliveryCount MessageMetadataKeys = "delivery-count"
+	// SourceConnection is the id of the `Connection` the message was received on.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysSourceConnection MessageMetadataKeys = "source-connection"
+	// TraceParent is the W3C Trace Context _traceparent_ header.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysTraceParent MessageMetadataKeys = "traceparent"
+	// TraceState is the W3C Trace Context _tracestate_ header.
+	//
+	// It must be a `string` value.
+	MessageMetadataKeysTraceState MessageMetadataKeys = "tracestate"
 )
 
 type QosLevel struct {
@@ -59,19 +105,191 @@ func (m *Message) RequestRetry() bool {
 	return m.GetMetadataBool(string(MessageMetadataKeysRetry), false)
 }
 
+// GetMetadataBool returns the `bool` metadata value of _key_, or _defaultValue_ if not set or not a `bool`.
+//
+// Since transports may convert the value, e.g. MQTT 5 user properties are strings, `string` values in the
+// `strconv.ParseBool` format are accepted as well.
 func (m *Message) GetMetadataBool(key string, defaultValue bool) bool {
-	if m.Metadata == nil {
-		return defaultValue
+	switch v := m.Metadata[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
 	}
-	value, ok := m.Metadata[key]
-	if !ok {
+
+	return defaultValue
+}
+
+// GetMetadataString returns the `string` metadata value of _key_, or _defaultValue_ if not set or not a `string`.
+func (m *Message) GetMetadataString(key string, defaultValue string) string {
+	if value, ok := m.Metadata[key].(string); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// GetMetadataInt returns the integer metadata value of _key_, or _defaultValue_ if not set, not an integer or
+// it does not fit in a `int`.
+//
+// Since transports may convert the value, integral `float64`, `json.Number` and numeric `string` values are
+// accepted as well as all integer types.
+func (m *Message) GetMetadataInt(key string, defaultValue int) int {
+	switch v := m.Metadata[key].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return intOr(v, defaultValue)
+	case uint:
+		return uintOr(uint64(v), defaultValue)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return uintOr(uint64(v), defaultValue)
+	case uint64:
+		return uintOr(v, defaultValue)
+	case float64:
+		// The float64 of `math.MaxInt64` rounds up to 2^63, hence the exclusive upper bound.
+		if v == math.Trunc(v) && v >= math.MinInt && v < math.MaxInt {
+			return int(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return intOr(i, defaultValue)
+		}
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+
+	return defaultValue
+}
+
+// intOr returns _v_ as `int` or _defaultValue_ if it overflows.
+func intOr(v int64, defaultValue int) int {
+	if v < math.MinInt || v > math.MaxInt {
 		return defaultValue
 	}
-	boolValue, ok := value.(bool)
-	if !ok {
+
+	return int(v)
+}
+
+// uintOr returns _v_ as `int` or _defaultValue_ if it overflows.
+func uintOr(v uint64, defaultValue int) int {
+	if v > math.MaxInt {
 		return defaultValue
 	}
-	return boolValue
+
+	return int(v)
+}
+
+// GetMetadataTime returns the `time.Time` metadata value of _key_, or _defaultValue_ if not set or not a time.
+//
+// RFC 3339 `string` values are accepted as well.
+func (m *Message) GetMetadataTime(key string, defaultValue time.Time) time.Time {
+	switch v := m.Metadata[key].(type) {
+	case time.Time:
+		return v
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	}
+
+	return defaultValue
+}
+
+// GetMetadataDuration returns the `time.Duration` metadata value of _key_, or _defaultValue_ if not set or not a
+// duration.
+//
+// `string` values in the `time.ParseDuration` format are accepted as well.
+func (m *Message) GetMetadataDuration(key string, defaultValue time.Duration) time.Duration {
+	switch v := m.Metadata[key].(type) {
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+
+	return defaultValue
+}
+
+// SetMetadata sets the metadata _key_ to _value_, the `Metadata` map is created if `nil`.
+//
+// NOTE: The `Metadata` map is shared between copies of the `Message`. Middlewares that forward a modified
+// message shall use `WithMetadata` instead, to not affect the message of the caller.
+func (m *Message) SetMetadata(key string, value any) {
+	if m.Metadata == nil {
+		m.Metadata = map[string]any{}
+	}
+
+	m.Metadata[key] = value
+}
+
+// SetMetadataString sets the `string` metadata _key_.
+func (m *Message) SetMetadataString(key string, value string) { m.SetMetadata(key, value) }
+
+// SetMetadataInt sets the `int` metadata _key_.
+func (m *Message) SetMetadataInt(key string, value int) { m.SetMetadata(key, value) }
+
+// SetMetadataTime sets the `time.Time` metadata _key_.
+func (m *Message) SetMetadataTime(key string, value time.Time) { m.SetMetadata(key, value) }
+
+// SetMetadataDuration sets the `time.Duration` metadata _key_.
+func (m *Message) SetMetadataDuration(key string, value time.Duration) { m.SetMetadata(key, value) }
+
+// WithMetadata returns a copy of the message, with a copy of the `Metadata`, where the _kv_ pairs are set.
+func (m *Message) WithMetadata(kv map[string]any) Message {
+	msg := *m
+
+	msg.Metadata = make(map[string]any, len(m.Metadata)+len(kv))
+	maps.Copy(msg.Metadata, m.Metadata)
+	maps.Copy(msg.Metadata, kv)
+
+	return msg
+}
+
+// MessageID returns the `MessageMetadataKeysMessageID` metadata, or empty string if not set.
+func (m *Message) MessageID() string {
+	return m.GetMetadataString(string(MessageMetadataKeysMessageID), "")
+}
+
+// CorrelationID returns the `MessageMetadataKeysCorrelationID` metadata, or empty string if not set.
+func (m *Message) CorrelationID() string {
+	return m.GetMetadataString(string(MessageMetadataKeysCorrelationID), "")
+}
+
+// ReplyTo returns the `MessageMetadataKeysReplyTo` metadata, or empty string if not set.
+func (m *Message) ReplyTo() string {
+	return m.GetMetadataString(string(MessageMetadataKeysReplyTo), "")
+}
+
+// ContentType returns the `MessageMetadataKeysContentType` metadata, or empty string if not set.
+func (m *Message) ContentType() string {
+	return m.GetMetadataString(string(MessageMetadataKeysContentType), "")
+}
+
+// PartitionKey returns the `MessageMetadataKeysPartitionKey` metadata, or empty string if not set.
+func (m *Message) PartitionKey() string {
+	return m.GetMetadataString(string(MessageMetadataKeysPartitionKey), "")
+}
+
+// DeliveryCount returns the `MessageMetadataKeysDeliveryCount` metadata, or zero if not set.
+func (m *Message) DeliveryCount() int {
+	return m.GetMetadataInt(string(MessageMetadataKeysDeliveryCount), 0)
 }
 
 // QosOrDefault returns the QoS level of the message, or the provided default if not set.
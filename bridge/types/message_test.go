@@ -0,0 +1,112 @@
+package types_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mariotoffia/gobridge/bridge/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessage_GetMetadataInt(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  int
+	}{
+		{"int", 42, 42},
+		{"int64", int64(-42), -42},
+		{"uint8", uint8(255), 255},
+		{"uint64", uint64(42), 42},
+		{"uint64 overflow", uint64(math.MaxUint64), -1},
+		{"uint overflow", uint(math.MaxInt) + 1, -1},
+		{"integral float64", float64(42), 42},
+		{"non-integral float64", 42.5, -1},
+		{"float64 overflow", math.MaxFloat64, -1},
+		{"float64 2^63", float64(1 << 63), -1},
+		{"json.Number", json.Number("42"), 42},
+		{"fractional json.Number", json.Number("4.2"), -1},
+		{"numeric string", "42", 42},
+		{"non-numeric string", "forty-two", -1},
+		{"bool", true, -1},
+		{"missing", nil, -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := types.Message{Metadata: map[string]any{}}
+			if tc.value != nil {
+				msg.Metadata["k"] = tc.value
+			}
+
+			assert.Equal(t, tc.want, msg.GetMetadataInt("k", -1))
+		})
+	}
+}
+
+func TestMessage_GetMetadataBool(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  bool
+	}{
+		{"bool", true, true},
+		{"string", "true", true},
+		{"string false", "false", false},
+		{"numeric string", "1", true},
+		{"non-bool string", "yes", true},
+		{"int", 1, true},
+		{"missing", nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := types.Message{Metadata: map[string]any{}}
+			if tc.value != nil {
+				msg.Metadata["k"] = tc.value
+			}
+
+			assert.Equal(t, tc.want, msg.GetMetadataBool("k", true))
+		})
+	}
+}
+
+func TestMessage_GetMetadataTimeAndDuration(t *testing.T) {
+	def := time.Unix(0, 0).UTC()
+	at := time.Date(2026, 10, 18, 12, 30, 0, 500, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  time.Time
+	}{
+		{"time.Time", at, at},
+		{"RFC3339", "2026-10-18T12:30:00Z", at.Truncate(time.Second)},
+		{"RFC3339 nano", "2026-10-18T12:30:00.0000005Z", at},
+		{"RFC3339 offset", "2026-10-18T14:30:00+02:00", at.Truncate(time.Second)},
+		{"not RFC3339", "18 Oct 2026", def},
+		{"unix seconds", int64(1790000000), def},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := types.Message{Metadata: map[string]any{"k": tc.value}}
+			assert.True(t, tc.want.Equal(msg.GetMetadataTime("k", def)), msg.GetMetadataTime("k", def))
+		})
+	}
+
+	msg := types.Message{Metadata: map[string]any{"d": "1m30s", "n": time.Second, "bad": "soon"}}
+	assert.Equal(t, 90*time.Second, msg.GetMetadataDuration("d", 0))
+	assert.Equal(t, time.Second, msg.GetMetadataDuration("n", 0))
+	assert.Equal(t, time.Hour, msg.GetMetadataDuration("bad", time.Hour))
+}
+
+func TestMessage_WithMetadata(t *testing.T) {
+	original := types.Message{Topic: "a", Metadata: map[string]any{"keep": 1, "override": "old"}}
+
+	msg := original.WithMetadata(map[string]any{"override": "new", "add": true})
+
+	assert.Equal(t, map[string]any{"keep": 1, "override": "new", "add": true}, msg.Metadata)
+	assert.Equal(t, map[string]any{"keep": 1, "override": "old"}, original.Metadata, "caller metadata must not be mutated")
+	assert.Equal(t, "a", msg.Topic)
+
+	var empty types.Message
+	assert.Equal(t, map[string]any{"k": "v"}, empty.WithMetadata(map[string]any{"k": "v"}).Metadata)
+	assert.Nil(t, empty.Metadata)
+}
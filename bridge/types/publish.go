@@ -43,6 +43,19 @@ type Publisher interface {
 	Publish(ctx context.Context, topic string, payload Message) error
 }
 
+// BatchPublisher is an optional interface a `Publisher` implements when the transport can send multiple messages
+// in a single request, e.g. SQS _SendMessageBatch_ or a Service Bus batch.
+//
+// It is detected by a type assertion on the transport `Publisher`, hence a `PublisherMiddleware` wrapping the
+// transport hides it unless it implements `BatchPublisher` as well.
+type BatchPublisher interface {
+	// PublishBatch sends the _payloads_ on _topic_ in as few requests as possible.
+	//
+	// It returns one error per payload, in the same order, where `nil` means that the payload was successfully
+	// published. The errors are the same as for `Publisher.Publish`.
+	PublishBatch(ctx context.Context, topic string, payloads []Message) []error
+}
+
 // PublisherAdapter is an adapter to allow the use of ordinary functions as `Publisher` interfaces.
 type PublisherAdapter func(ctx context.Context, topic string, payload Message) error
 
@@ -70,9 +70,20 @@ type Subscriber interface {
 	//
 	// When the `Connection` do not support re-sends, all errors returned by the `Subscriber` are ignored and
 	// dropped.
+	//
+	// When the `Connection` supports explicit settlement, an `Acknowledger` is available in _ctx_
+	// (see `AcknowledgerFromContext`) and the return value is ignored once the `Subscriber` has used it.
 	Process(ctx context.Context, topic string, payload Message) error
 }
 
+// SubscriberRouter is a `SubscriberSource` that is itself a `Subscriber`. It is registered on a `SubscriberSource`
+// (usually with a catch-all wildcard topic) and multiplexes each received message to all its registered
+// `Subscriber` instances whose topic pattern matches the concrete `Message.Topic`.
+type SubscriberRouter interface {
+	SubscriberSource
+	Subscriber
+}
+
 // SubscriberConfigSource is a `SubscriberConfigSource` that can accept configuration changes during runtime.
 //
 // If a `SubscriberSource` do not implement this interface, it means that the `SubscriberSource` is only accepts